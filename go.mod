@@ -2,4 +2,9 @@ module wget
 
 go 1.23.5
 
-require golang.org/x/net v0.33.0
+require (
+	github.com/andybalholm/brotli v1.2.0
+	golang.org/x/net v0.33.0
+)
+
+require golang.org/x/text v0.21.0 // indirect
@@ -17,8 +17,8 @@ func main() {
 	// Initialize the WgetApp instance using the singleton pattern
 	_, err := wgetApp.InitWget()
 	if err != nil {
-		// Print any initialization errors and exit
+		// Print any initialization errors and exit with a failure status
 		fmt.Println(err)
-		return
+		os.Exit(1)
 	}
 }
@@ -41,12 +41,31 @@ func (app *WgetApp) asyncMirror(outputFile, urls, direc string) error {
 	fullDirPath := filepath.Join(rootPath, relativeDirPath)
 	fileName := pathComponents[len(pathComponents)-1]
 
-	resp, err := wgetutils.HttpRequest(urls)
+	// With -N, ask for the page only if it changed since the local copy was saved. Pages
+	// served as text/html may have been saved with an extra .html suffix.
+	headers := map[string]string{}
+	localCopy := ""
+	if app.urlArgs.timestamping && outputFile == "" && fileName != "" && !strings.HasSuffix(urls, "/") {
+		for _, candidate := range []string{filepath.Join(fullDirPath, fileName), filepath.Join(fullDirPath, fileName+".html")} {
+			if since := wgetutils.IfModifiedSince(candidate); since != "" {
+				localCopy = candidate
+				headers["If-Modified-Since"] = since
+				break
+			}
+		}
+	}
+
+	resp, err := wgetutils.HttpRequestWithHeaders(urls, headers)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	if localCopy != "" && wgetutils.IsUpToDate(resp, localCopy) {
+		fmt.Printf("Not modified: %s\n", urls)
+		return nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("error: status %s\nurl: %s", resp.Status, urls)
 	}
@@ -76,7 +95,14 @@ func (app *WgetApp) asyncMirror(outputFile, urls, direc string) error {
 		}
 	}
 
-	if wgetutils.FileExists(outputFile) {
+	// Like wget's recursive mode, a page fetched again replaces the old copy unless -nc
+	// or --backups say otherwise
+	policy := wgetutils.ClobberPolicy{NoClobber: app.urlArgs.noClobber, Backups: app.urlArgs.backups}
+	outputFile, err = policy.PrepareOutput(outputFile)
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
 		return nil
 	}
 
@@ -106,7 +132,7 @@ func (app *WgetApp) asyncMirror(outputFile, urls, direc string) error {
 	for {
 		n, err := reader.Read(buffer)
 		if err != nil && err != io.EOF {
-			return fmt.Errorf("error reading response body")
+			return fmt.Errorf("error reading response body:\n%v", err)
 		}
 
 		if n > 0 {
@@ -114,7 +140,8 @@ func (app *WgetApp) asyncMirror(outputFile, urls, direc string) error {
 				return fmt.Errorf("error writing to file:\n%v", err)
 			}
 			downloaded += int64(n)
-			app.progress(downloaded, size, start)
+			current, total := wireProgress(resp.Body, downloaded, size)
+			app.progress(current, total, start)
 		}
 
 		if err == io.EOF {
@@ -124,6 +151,12 @@ func (app *WgetApp) asyncMirror(outputFile, urls, direc string) error {
 
 	fmt.Printf("\n\033[32mDownloaded [%s]\033[0m\n", urls)
 
+	if app.urlArgs.timestamping {
+		if err := wgetutils.ApplyLastModified(outputFile, resp.Header); err != nil {
+			return err
+		}
+	}
+
 	// Mark the URL as processed
 	app.processedURLs.Lock()
 	app.processedURLs.urls[urls] = true
@@ -5,21 +5,23 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	wgetutils "wget/wgetUtils"
 )
 
+// passwordEnv and proxyPasswordEnv carry passwords to background downloads without
+// exposing them in ps output.
+const (
+	passwordEnv      = "WGET_PASSWORD"
+	proxyPasswordEnv = "WGET_PROXY_PASSWORD"
+)
+
 // downloadInBackground downloads a file in the background while logging output to "wget-log".
 func (app *WgetApp) downloadInBackground(file, urlStr string) error {
-	// Parse the URL to derive the output name
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
+	if _, err := url.Parse(urlStr); err != nil {
 		return fmt.Errorf("invalid URL")
 	}
-	outputName := filepath.Base(parsedURL.Path) // Get the file name from the URL
-	if file != "" {
-		outputName = file
-	}
+	// Without -O, the child names the file itself, from Content-Disposition or the URL
+	outputName := file
 	path := "." // Default path to save the file
 	// Create the wget-log file to log output
 	logFile, err := os.OpenFile("wget-log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
@@ -32,7 +34,15 @@ func (app *WgetApp) downloadInBackground(file, urlStr string) error {
 	if err := os.MkdirAll(path, os.ModePerm); err != nil {
 		return fmt.Errorf("error creating output directory:\n%v", err)
 	}
-	cmd := exec.Command(os.Args[0], "-O="+outputName, "-P="+path, urlStr)
+	args := append([]string{"-P=" + path}, app.urlArgs.forwardArgs...)
+	if outputName != "" {
+		args = append([]string{"-O=" + outputName}, args...)
+	}
+	cmd := exec.Command(os.Args[0], append(args, urlStr)...)
+	// Keep passwords out of the child's argument list, where any user could read them
+	cmd.Env = append(os.Environ(),
+		passwordEnv+"="+app.urlArgs.client.Password,
+		proxyPasswordEnv+"="+app.urlArgs.client.ProxyPassword)
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
 	fmt.Println("Output will be written to \"wget-log\".")
@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	wgetutils "wget/wgetUtils"
 )
 
 func TestDownloadInBackgroundInvalidURL(t *testing.T) {
@@ -142,3 +145,27 @@ func TestDownloadInBackground(t *testing.T) {
 	// Clean up
 	os.Remove(fileName)
 }
+
+func TestSaveCookiesLeftToBackgroundChild(t *testing.T) {
+	cookieFile := filepath.Join(t.TempDir(), "cookies.txt")
+	app := newWgetState()
+	app.cookieJar = wgetutils.NewCookieJar()
+	app.urlArgs.saveCookies = cookieFile
+	app.urlArgs.workInBackground = true
+
+	if err := app.saveCookies(nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(cookieFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the parent not to write %s, but got %v", cookieFile, err)
+	}
+
+	// Without -B the jar is saved once the download is done
+	app.urlArgs.workInBackground = false
+	if err := app.saveCookies(nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(cookieFile); err != nil {
+		t.Errorf("Expected %s to be written, but got %v", cookieFile, err)
+	}
+}
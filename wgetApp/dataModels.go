@@ -1,19 +1,53 @@
 package wgetApp
 
-import "sync"
+import (
+	"sync"
+
+	wgetutils "wget/wgetUtils"
+)
 
 // UrlArgs struct with exported fields (Uppercase names)
 type UrlArgs struct {
-	url              string
-	file             string
-	rateLimit        string
-	path             string
-	sourceFile       string
-	workInBackground bool
-	mirroring        bool
-	rejectFlag       string
-	excludeFlag      string
-	convertLinksFlag bool
+	url                string
+	file               string
+	rateLimit          string
+	path               string
+	sourceFile         string
+	workInBackground   bool
+	mirroring          bool
+	filter             wgetutils.URLFilter // -A, -R, -I, -X and the regex filters
+	convertLinksFlag   bool
+	pageRequisites     bool // -p: download one page and what it needs to render
+	level              int  // --level: how many links deep to follow, 0 for no limit
+	ignoreRobots       bool // -e robots=off
+	noParent           bool // -np: never crawl pages above the start directory
+	spanHosts          bool // -H: crawl pages and requisites on other hosts
+	spanRequisites     bool // download requisites from other hosts without crawling their pages
+	domains            wgetutils.DomainFilter
+	continueDownload   bool
+	segments           int
+	parallel           int
+	retry              wgetutils.RetryPolicy
+	client             wgetutils.ClientOptions
+	loadCookies        string
+	saveCookies        string
+	keepSessionCookies bool
+	askPassword        bool
+	netrcFile          string
+
+	method             string
+	body               []byte
+	contentDisposition bool
+	timestamping       bool
+	noClobber          bool
+	backups            int
+	checksum           *wgetutils.Checksum
+	checksumSidecar    bool
+	metalinkFile       string
+	metalinkAuto       bool
+	trustServerNames   bool
+
+	forwardArgs []string // request options passed on to background downloads
 }
 
 // ProcessedURLs is a thread-safe structure that holds a collection of URLs
@@ -24,25 +58,40 @@ type ProcessedURLs struct {
 
 // WgetApp encapsulates global variables and synchronization primitives
 type WgetApp struct {
-	urlArgs        UrlArgs
-	processedURLs  ProcessedURLs
-	visitedPages   map[string]bool
-	visitedAssets  map[string]bool
-	muPages        sync.Mutex
-	muAssets       sync.Mutex
-	count          int
-	tempConfigFile string
+	urlArgs         UrlArgs
+	processedURLs   ProcessedURLs
+	visitedPages    map[string]bool
+	pageDepths      map[string]int // the shortest link depth each page was crawled at
+	mirrorDomain    string         // the host the mirror started on
+	mirrorDirectory string         // the directory of the start URL, for --no-parent
+	visitedAssets   map[string]bool
+	muPages         sync.Mutex
+	muAssets        sync.Mutex
+	robots          map[string]*robotsHost // robots.txt rules per scheme://host
+	muRobots        sync.Mutex
+	outputs         map[string]bool // output paths claimed by downloads still in progress
+	muOutput        sync.Mutex      // serializes claiming output names between --parallel workers
+	count           int
+	tempConfigFile  string
+	hideProgress    bool
+	cookieJar       *wgetutils.CookieJar
 }
 
 // newWgetState initializes and returns a new instance of WgetApp.
 func newWgetState() *WgetApp {
 	return &WgetApp{
 		visitedPages:  make(map[string]bool),
+		pageDepths:    make(map[string]int),
 		visitedAssets: make(map[string]bool),
+		robots:        make(map[string]*robotsHost),
+		outputs:       make(map[string]bool),
 		processedURLs: ProcessedURLs{
 			urls: make(map[string]bool),
 		},
-		count: 0,
+		urlArgs: UrlArgs{
+			retry: wgetutils.DefaultRetryPolicy(),
+		},
+		count:          0,
 		tempConfigFile: "progress_config.txt",
 	}
 }
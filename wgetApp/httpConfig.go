@@ -0,0 +1,51 @@
+package wgetApp
+
+import (
+	wgetutils "wget/wgetUtils"
+)
+
+// configureHTTP applies the request options parsed from the command line to the shared
+// HTTP layer in wgetutils, so single, batch and mirror downloads all behave the same way.
+func (app *WgetApp) configureHTTP() error {
+	wgetutils.SetRetryPolicy(app.urlArgs.retry)
+
+	// One jar for the whole run, so a session started by one request carries over to the rest
+	app.cookieJar = wgetutils.NewCookieJar()
+	if app.urlArgs.loadCookies != "" {
+		if err := app.cookieJar.LoadCookies(app.urlArgs.loadCookies); err != nil {
+			return err
+		}
+	}
+	app.urlArgs.client.Jar = app.cookieJar
+
+	// An explicit --netrc-file must exist; the default ~/.netrc is optional
+	netrcFile := app.urlArgs.netrcFile
+	if netrcFile == "" {
+		if path, err := wgetutils.DefaultNetrcPath(); err == nil && wgetutils.FileExists(path) {
+			netrcFile = path
+		}
+	}
+	if netrcFile != "" {
+		netrc, err := wgetutils.LoadNetrc(netrcFile)
+		if err != nil {
+			return err
+		}
+		app.urlArgs.client.Netrc = netrc
+	}
+
+	return wgetutils.SetClientOptions(app.urlArgs.client)
+}
+
+// saveCookies writes the cookie jar to the --save-cookies file once all downloads are done.
+// The jar is saved even when a download failed, and an earlier error takes precedence.
+// With -B the download is still running in the child, which saves the file itself.
+func (app *WgetApp) saveCookies(err error) error {
+	if app.cookieJar == nil || app.urlArgs.saveCookies == "" || app.urlArgs.workInBackground {
+		return err
+	}
+	saveErr := app.cookieJar.SaveCookies(app.urlArgs.saveCookies, app.urlArgs.keepSessionCookies)
+	if err != nil {
+		return err
+	}
+	return saveErr
+}
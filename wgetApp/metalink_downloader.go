@@ -0,0 +1,34 @@
+package wgetApp
+
+import (
+	"fmt"
+
+	wgetutils "wget/wgetUtils"
+)
+
+// maxMetalinkSize caps how much of a response is read as a Metalink document.
+const maxMetalinkSize = 10 << 20
+
+// downloadMetalink downloads every file listed in a Metalink document. Each file is tried
+// from its mirrors in priority order, moving on to the next mirror when a download fails
+// or does not match the listed size and digest. A failed file does not stop the others.
+func (app *WgetApp) downloadMetalink(metalink *wgetutils.Metalink, limit, directory string) error {
+	results := make([]downloadResult, 0, len(metalink.Files))
+
+	for i := range metalink.Files {
+		file := &metalink.Files[i]
+		mirrors := file.MirrorURLs()
+
+		err := fmt.Errorf("error: no mirrors listed for %s", file.Name)
+		for n, mirror := range mirrors {
+			fmt.Printf("metalink: fetching %s from mirror %d of %d [%s]\n", file.Name, n+1, len(mirrors), mirror)
+			if err = app.downloadFile(file.Name, mirror, limit, directory, file); err == nil {
+				break
+			}
+			fmt.Printf("mirror failed: %v\n\n", err)
+		}
+		results = append(results, downloadResult{url: file.Name, err: err})
+	}
+
+	return printSummary(results)
+}
@@ -0,0 +1,76 @@
+package wgetApp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	wgetutils "wget/wgetUtils"
+)
+
+func TestDownloadMetalink(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/corrupt/release.tar.gz":
+			w.Write([]byte("hello there\n"))
+		case "/good/release.tar.gz":
+			w.Write([]byte("hello world\n"))
+		case "/release.meta4":
+			w.Header().Set("Content-Type", wgetutils.MetalinkMediaType)
+			fmt.Fprintf(w, `<metalink xmlns="urn:ietf:params:xml:ns:metalink">
+  <file name="release.tar.gz">
+    <size>12</size>
+    <hash type="sha-256">a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447</hash>
+    <url priority="3">%[1]s/good/release.tar.gz</url>
+    <url priority="2">%[1]s/corrupt/release.tar.gz</url>
+    <url priority="1">%[1]s/missing/release.tar.gz</url>
+  </file>
+</metalink>`, server.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.metalinkAuto = true
+
+	// The missing and corrupt mirrors are skipped in favor of the good one
+	if err := app.singleDownloader("", server.URL+"/release.meta4", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "release.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello world\n" {
+		t.Errorf("Expected the verified file from the good mirror, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "release.meta4")); err == nil {
+		t.Errorf("Expected the metalink document not to be saved")
+	}
+}
+
+func TestDownloadMetalinkAllMirrorsFail(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+
+	metalink := &wgetutils.Metalink{Files: []wgetutils.MetalinkFile{{
+		Name: "release.tar.gz",
+		URLs: []wgetutils.MetalinkURL{{URL: server.URL + "/a"}, {URL: server.URL + "/b"}},
+	}}}
+	if err := app.downloadMetalink(metalink, "", tempDir); err == nil {
+		t.Errorf("Expected an error when every mirror fails")
+	}
+}
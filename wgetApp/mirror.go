@@ -2,6 +2,7 @@ package wgetApp
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"regexp"
 	"strings"
@@ -13,15 +14,38 @@ import (
 )
 
 // mirror fetches the content of a URL, processes it to extract links and assets,
-// and downloads them if they belong to the same domain. It supports handling of
+// and downloads them if they belong to the same domain, or to a host allowed by
+// --span-hosts or --span-requisites. It supports handling of
 // inline styles and <style> tags, as well as recursive mirroring of linked pages.
 // It also handles URL conversion for offline viewing if the convertLink flag is true.
-func (app *WgetApp) mirror(url, rejectTypes, rejectPaths string, convertLink bool) error {
+func (app *WgetApp) mirror(url string, convertLink bool) error {
+	app.mirrorDomain, _ = wgetutils.ExtractDomain(url)
+	app.mirrorDirectory = wgetutils.StartDirectory(url)
+	return app.mirrorPage(url, 0, convertLink)
+}
+
+// pageRequisites downloads a single page together with everything it needs to render:
+// stylesheets, scripts, images, fonts and media. Its anchors are not followed.
+func (app *WgetApp) pageRequisites(url string, convertLink bool) error {
+	app.mirrorDomain, _ = wgetutils.ExtractDomain(url)
+	app.mirrorDirectory = wgetutils.StartDirectory(url)
+
+	// The page itself is saved here, so mirrorPage need not fetch a root index.html
+	app.count++
+	app.downloadAsset(url, app.mirrorDomain)
+	return app.mirrorPage(url, 0, convertLink)
+}
+
+// mirrorPage mirrors a page found depth links away from the start URL. A page already
+// crawled is only crawled again when it is reached through a shorter path, since that
+// may allow following links that were cut off by --level before.
+func (app *WgetApp) mirrorPage(url string, depth int, convertLink bool) error {
 	app.muPages.Lock()
-	if app.visitedPages[url] {
+	if crawled, exists := app.pageDepths[url]; exists && crawled <= depth {
 		app.muPages.Unlock()
 		return nil
 	}
+	app.pageDepths[url] = depth
 	app.visitedPages[url] = true
 	app.muPages.Unlock()
 
@@ -34,7 +58,11 @@ func (app *WgetApp) mirror(url, rejectTypes, rejectPaths string, convertLink boo
 	if (strings.TrimRight(url, "/") == "http://"+domain || strings.TrimRight(url, "/") == "https://"+domain) && app.count == 0 {
 		app.count++
 		indexURL := strings.TrimRight(url, "/")
-		app.downloadAsset(indexURL, domain, rejectTypes)
+		app.downloadAsset(indexURL, domain)
+	}
+
+	if !app.allowCrawl(url) {
+		return nil
 	}
 
 	// Fetch and get the HTML of the page
@@ -43,41 +71,60 @@ func (app *WgetApp) mirror(url, rejectTypes, rejectPaths string, convertLink boo
 		return fmt.Errorf("error fetching or parsing page:\n%v", err)
 	}
 
-	// Start processing the document
-	app.processNode(url, rejectPaths, domain, rejectTypes, convertLink, doc)
+	// Start processing the document, following its links unless it asks robots not to
+	follow := app.urlArgs.ignoreRobots || !wgetutils.MetaRobotsNofollow(doc)
+	app.processNode(url, depth, follow, convertLink, doc)
 
 	// Convert links if the flag is set
-	if convertLink {
+	if convertLink && app.urlArgs.pageRequisites {
+		wgetutils.ConvertRequisiteLinks(url)
+	} else if convertLink {
 		wgetutils.ConvertLinks(url)
 	}
 	return nil
 }
 
 // processNode
-func (app *WgetApp) processNode(url, rejectPaths, domain, rejectTypes string, convertLink bool, n *html.Node) {
+func (app *WgetApp) processNode(url string, depth int, follow, convertLink bool, n *html.Node) {
 	var wg sync.WaitGroup
 
 	if n.Type == html.ElementNode {
+		// Links the page or the anchor marks as nofollow are not crawled
+		nofollow := n.Data == "a" && (!follow || (!app.urlArgs.ignoreRobots && wgetutils.IsNofollowLink(n)))
+		// A single-page snapshot skips <link>s to other documents, such as rel="next"
+		if n.Data == "link" && app.urlArgs.pageRequisites && !wgetutils.IsRequisiteLink(n) {
+			nofollow = true
+		}
+		stylesheet := n.Data == "link" && wgetutils.IsStylesheetLink(n)
 		for _, attr := range n.Attr {
-			if wgetutils.IsValidAttribute(n.Data, attr.Key) {
-				link := attr.Val
-				if link != "" {
+			if wgetutils.IsValidAttribute(n.Data, attr.Key) && !nofollow {
+				links := []string{attr.Val}
+				if attr.Key == "srcset" {
+					links = wgetutils.SrcsetURLs(attr.Val)
+				}
+				for _, link := range links {
+					if link == "" {
+						continue
+					}
 					wg.Add(1)
 					go func(link, tagName string) {
 						defer wg.Done()
-						app.handleLink(url, rejectPaths, link, tagName, domain, rejectTypes, convertLink)
+						app.handleLink(url, depth, link, tagName, convertLink)
+						if stylesheet {
+							app.handleStylesheet(url, link)
+						}
 						// <-app.semaphore
 					}(link, n.Data)
 				}
 			}
 			// Check for inline styles
 			if attr.Key == "style" {
-				app.extractAndHandleStyleURLs(attr.Val, url, domain, rejectTypes)
+				app.extractAndHandleStyleURLs(attr.Val, url)
 			}
 		}
 		// Check for <style> tags
 		if n.Data == "style" && n.FirstChild != nil {
-			app.extractAndHandleStyleURLs(n.FirstChild.Data, url, domain, rejectTypes)
+			app.extractAndHandleStyleURLs(n.FirstChild.Data, url)
 		}
 	}
 
@@ -85,15 +132,24 @@ func (app *WgetApp) processNode(url, rejectPaths, domain, rejectTypes string, co
 	wg.Wait()
 
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		app.processNode(url, rejectPaths, domain, rejectTypes, convertLink, c)
+		app.processNode(url, depth, follow, convertLink, c)
 	}
 }
 
-// Function to handle links and assets found on the page
-func (app *WgetApp) handleLink(url, rejectPaths, link, tagName, domain, rejectTypes string, convertLink bool) {
+// Function to handle links and assets found on the page. Page requisites are always
+// fetched, but anchors are not followed past the --level limit, nor at all with -p. Assets are saved under
+// a directory named after their own host.
+func (app *WgetApp) handleLink(url string, depth int, link, tagName string, convertLink bool) {
+	if tagName == "a" && (app.urlArgs.pageRequisites || !app.withinLevel(depth+1)) {
+		return
+	}
+
 	baseURL := wgetutils.ResolveURL(url, link)
-	if wgetutils.IsRejectedPath(baseURL, rejectPaths) {
-		fmt.Printf("Skipping Rejected file path: %s\n", baseURL)
+	if !wgetutils.IsWebURL(baseURL) {
+		return
+	}
+	if err := app.urlArgs.filter.CheckTraversal(baseURL); err != nil {
+		fmt.Printf("Skipping %s (%v)\n", baseURL, err)
 		return
 	}
 	baseURLDomain, err := wgetutils.ExtractDomain(baseURL)
@@ -102,25 +158,56 @@ func (app *WgetApp) handleLink(url, rejectPaths, link, tagName, domain, rejectTy
 		return
 	}
 
-	if baseURLDomain == domain {
-		if tagName == "a" {
-			if strings.HasSuffix(baseURL, "/") || strings.HasSuffix(baseURL, "/index.html") {
-				// Ensure index.html is downloaded first
-				indexURL := strings.TrimRight(baseURL, "/") + "/index.html"
-				if !app.visitedPages[indexURL] {
-					app.downloadAsset(indexURL, domain, rejectTypes)
-					app.mirror(indexURL, rejectTypes, rejectPaths, convertLink)
-				}
-			} else {
-				app.mirror(baseURL, rejectTypes, rejectPaths, convertLink)
-			}
+	// Anchors lead to pages to crawl, everything else is a requisite of this page
+	if !app.followsHost(baseURLDomain, tagName != "a") {
+		return
+	}
+	if tagName == "a" && !app.withinParent(baseURL, baseURLDomain) {
+		return
+	}
+	if tagName == "a" {
+		if strings.HasSuffix(baseURL, "/") || strings.HasSuffix(baseURL, "/index.html") {
+			// Ensure index.html is downloaded first
+			indexURL := strings.TrimRight(baseURL, "/") + "/index.html"
+			app.downloadAsset(indexURL, baseURLDomain)
+			app.mirrorPage(indexURL, depth+1, convertLink)
+		} else {
+			app.mirrorPage(baseURL, depth+1, convertLink)
 		}
-		app.downloadAsset(baseURL, domain, rejectTypes)
 	}
+	app.downloadAsset(baseURL, baseURLDomain)
+}
+
+// followsHost reports whether the mirror may download from host. Other hosts than the
+// start host need -H, or --span-requisites for a page requisite, and must pass -D and
+// --exclude-domains.
+func (app *WgetApp) followsHost(host string, requisite bool) bool {
+	if host == app.mirrorDomain {
+		return true
+	}
+	if !app.urlArgs.spanHosts && !(requisite && app.urlArgs.spanRequisites) {
+		return false
+	}
+	return app.urlArgs.domains.Allows(host)
+}
+
+// withinParent reports whether --no-parent lets the mirror crawl the page at pageURL: a
+// page on the start host has to be in the start directory or below it.
+func (app *WgetApp) withinParent(pageURL, host string) bool {
+	if !app.urlArgs.noParent || host != app.mirrorDomain {
+		return true
+	}
+	return wgetutils.IsUnderDirectory(pageURL, app.mirrorDirectory)
+}
+
+// withinLevel reports whether a page depth links away from the start URL may still be
+// downloaded. A level of 0 means there is no limit.
+func (app *WgetApp) withinLevel(depth int) bool {
+	return app.urlArgs.level == 0 || depth <= app.urlArgs.level
 }
 
 // downloadAsset checks if the asset URL has been visited, validates the URL, and initiates the download process.
-func (app *WgetApp) downloadAsset(fileURL, domain, rejectTypes string) {
+func (app *WgetApp) downloadAsset(fileURL, domain string) {
 	app.muAssets.Lock()
 	if app.visitedAssets[fileURL] {
 		app.muAssets.Unlock()
@@ -134,8 +221,12 @@ func (app *WgetApp) downloadAsset(fileURL, domain, rejectTypes string) {
 		return
 	}
 
-	if wgetutils.IsRejected(fileURL, rejectTypes) {
-		fmt.Printf("Skipping rejected file: %s\n", fileURL)
+	if err := app.urlArgs.filter.Check(fileURL); err != nil {
+		fmt.Printf("Skipping %s (%v)\n", fileURL, err)
+		return
+	}
+
+	if !app.allowCrawl(fileURL) {
 		return
 	}
 
@@ -158,16 +249,82 @@ func fetchAndParsePage(url string) (*html.Node, error) {
 	return html.Parse(resp.Body)
 }
 
+// handleStylesheet fetches a stylesheet linked from the page at pageURL and downloads
+// what it refers to, such as fonts, background images and @import-ed stylesheets.
+func (app *WgetApp) handleStylesheet(pageURL, link string) {
+	cssURL := wgetutils.ResolveURL(pageURL, link)
+	if !wgetutils.IsWebURL(cssURL) {
+		return
+	}
+	host, err := wgetutils.ExtractDomain(cssURL)
+	if err != nil || !app.followsHost(host, true) || app.urlArgs.filter.CheckTraversal(cssURL) != nil {
+		return
+	}
+
+	app.muPages.Lock()
+	if app.visitedPages[cssURL] {
+		app.muPages.Unlock()
+		return
+	}
+	app.visitedPages[cssURL] = true
+	app.muPages.Unlock()
+
+	if !app.allowCrawl(cssURL) {
+		return
+	}
+	resp, err := wgetutils.HttpRequest(cssURL)
+	if err != nil {
+		fmt.Printf("Could not fetch stylesheet %s:\n%v\n", cssURL, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxStylesheetSize))
+	if err != nil {
+		fmt.Printf("Could not read stylesheet %s:\n%v\n", cssURL, err)
+		return
+	}
+	app.extractAndHandleStyleURLs(string(data), cssURL)
+}
+
+// maxStylesheetSize caps how much of a stylesheet is read when looking for requisites.
+const maxStylesheetSize = 10 << 20
+
 // extractAndHandleStyleURLs processes the URLs found in a CSS style block.
 // It resolves relative URLs to absolute ones based on the base URL and downloads the assets,
-// checking against domain restrictions and rejected types.
-func (app *WgetApp) extractAndHandleStyleURLs(styleContent, baseURL, domain, rejectTypes string) {
+// checking against domain restrictions and rejected types. Stylesheets pulled in with
+// @import are fetched and processed in turn.
+func (app *WgetApp) extractAndHandleStyleURLs(styleContent, baseURL string) {
 	re := regexp.MustCompile(`url\(['"]?([^'"()]+)['"]?\)`)
 	matches := re.FindAllStringSubmatch(styleContent, -1)
 	for _, match := range matches {
 		if len(match) > 1 {
 			assetURL := wgetutils.ResolveURL(baseURL, match[1])
-			app.downloadAsset(assetURL, domain, rejectTypes)
+			if !wgetutils.IsWebURL(assetURL) {
+				continue
+			}
+			assetDomain, err := wgetutils.ExtractDomain(assetURL)
+			if err != nil || !app.followsHost(assetDomain, true) {
+				continue
+			}
+			app.downloadAsset(assetURL, assetDomain)
+		}
+	}
+
+	imports := regexp.MustCompile(`@import\s+(?:url\()?['"]?([^'"()\s;]+)`)
+	for _, match := range imports.FindAllStringSubmatch(styleContent, -1) {
+		importURL := wgetutils.ResolveURL(baseURL, match[1])
+		if !wgetutils.IsWebURL(importURL) {
+			continue
+		}
+		importDomain, err := wgetutils.ExtractDomain(importURL)
+		if err != nil || !app.followsHost(importDomain, true) {
+			continue
 		}
+		app.downloadAsset(importURL, importDomain)
+		app.handleStylesheet(baseURL, match[1])
 	}
 }
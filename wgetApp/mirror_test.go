@@ -1,22 +1,30 @@
 package wgetApp
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+
+	wgetutils "wget/wgetUtils"
 )
 
 func TestMirror(t *testing.T) {
 	app := newWgetState()
 	
 	t.Run("Valid URL", func(t *testing.T) {
-		err := app.mirror("http://example.com", "", "", false)
+		err := app.mirror("http://example.com", false)
 		if err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 	})
 
 	t.Run("Invalid URL", func(t *testing.T) {
-		err := app.mirror("invalid_url", "", "", true)
+		err := app.mirror("invalid_url", true)
 		if err == nil {
 			t.Errorf("Expected error, got nil")
 		}
@@ -28,16 +36,368 @@ func TestDownloadAsset(t *testing.T) {
 	app.muAssets = sync.Mutex{}
 
 	t.Run("Valid Asset", func(t *testing.T) {
-		app.downloadAsset("http://example.com/image.jpg", "example.com", "")
+		app.downloadAsset("http://example.com/image.jpg", "example.com")
 		if !app.visitedAssets["http://example.com/image.jpg"] {
 			t.Errorf("Expected asset to be visited")
 		}
 	})
 
 	t.Run("Invalid Asset", func(t *testing.T) {
-		app.downloadAsset("", "example.com", "")
+		app.downloadAsset("", "example.com")
 		if _, exists := app.visitedAssets[" "]; exists {
 			t.Errorf("Expected asset to be ignored")
 		}
 	})
 }
+
+func TestMirrorLevel(t *testing.T) {
+	pages := map[string]string{
+		"/":           `<a href="/one.html">one</a><img src="/zero.png">`,
+		"/one.html":   `<a href="/two.html">two</a><img src="/one.png">`,
+		"/two.html":   `<a href="/three.html">three</a><img src="/two.png">`,
+		"/three.html": `<img src="/three.png">`,
+	}
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		if page, ok := pages[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(page))
+			return
+		}
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	// Mirrored files are saved under a directory named after the host
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		level    int
+		fetched  []string
+		excluded []string
+	}{
+		{1, []string{"/one.html", "/one.png"}, []string{"/two.html", "/two.png"}},
+		{2, []string{"/two.html", "/two.png"}, []string{"/three.html", "/three.png"}},
+		{0, []string{"/three.html", "/three.png"}, nil},
+	}
+
+	for _, test := range tests {
+		requested = map[string]bool{}
+		app := newWgetState()
+		app.urlArgs.level = test.level
+		if err := app.mirror(server.URL+"/", false); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+		for _, path := range test.fetched {
+			if !requested[path] {
+				t.Errorf("Expected %s to be fetched with level %d", path, test.level)
+			}
+		}
+		for _, path := range test.excluded {
+			if requested[path] {
+				t.Errorf("Expected %s not to be fetched with level %d", path, test.level)
+			}
+		}
+	}
+}
+
+func TestMirrorRobots(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="/private/a.html">a</a><a rel="nofollow" href="/b.html">b</a><a href="/c.html">c</a>`))
+		case "/c.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<meta name="robots" content="nofollow"><a href="/d.html">d</a><img src="/c.png">`))
+		default:
+			w.Write([]byte("content"))
+		}
+	}))
+	defer server.Close()
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		ignoreRobots bool
+		fetched      []string
+		excluded     []string
+	}{
+		{false, []string{"/robots.txt", "/c.html", "/c.png"}, []string{"/private/a.html", "/b.html", "/d.html"}},
+		{true, []string{"/private/a.html", "/b.html", "/d.html"}, []string{"/robots.txt"}},
+	}
+
+	for _, test := range tests {
+		requested = map[string]bool{}
+		app := newWgetState()
+		app.urlArgs.ignoreRobots = test.ignoreRobots
+		if err := app.mirror(server.URL+"/", false); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+		for _, path := range test.fetched {
+			if !requested[path] {
+				t.Errorf("Expected %s to be fetched with robots=off %v", path, test.ignoreRobots)
+			}
+		}
+		for _, path := range test.excluded {
+			if requested[path] {
+				t.Errorf("Expected %s not to be fetched with robots=off %v", path, test.ignoreRobots)
+			}
+		}
+	}
+}
+
+func TestMirrorSpanHosts(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	var otherHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := strings.Cut(r.Host, ":")
+		mu.Lock()
+		requested[host+r.URL.Path] = true
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<img src="http://%s/logo.png"><a href="http://%s/other.html">other</a>`, otherHost, otherHost)
+		case "/other.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<img src="/other.png">`))
+		default:
+			w.Write([]byte("content"))
+		}
+	}))
+	defer server.Close()
+	// The same server under another name stands in for a second host
+	otherHost = strings.Replace(strings.TrimPrefix(server.URL, "http://"), "127.0.0.1", "localhost", 1)
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		name     string
+		args     UrlArgs
+		fetched  []string
+		excluded []string
+	}{
+		{"same host only", UrlArgs{},
+			nil, []string{"localhost/logo.png", "localhost/other.html"}},
+		{"span requisites", UrlArgs{spanRequisites: true},
+			[]string{"localhost/logo.png"}, []string{"localhost/other.html"}},
+		{"span hosts", UrlArgs{spanHosts: true},
+			[]string{"localhost/logo.png", "localhost/other.html", "localhost/other.png"}, nil},
+		{"excluded domain", UrlArgs{spanHosts: true, domains: wgetutils.DomainFilter{Exclude: []string{"localhost"}}},
+			nil, []string{"localhost/logo.png", "localhost/other.html"}},
+		{"domain not listed", UrlArgs{spanHosts: true, domains: wgetutils.DomainFilter{Domains: []string{"example.com"}}},
+			nil, []string{"localhost/logo.png", "localhost/other.html"}},
+	}
+
+	for _, test := range tests {
+		requested = map[string]bool{}
+		app := newWgetState()
+		app.urlArgs = test.args
+		if err := app.mirror(server.URL+"/", false); err != nil {
+			t.Fatalf("%s: expected nil, got %v", test.name, err)
+		}
+		for _, path := range test.fetched {
+			if !requested[path] {
+				t.Errorf("%s: expected %s to be fetched", test.name, path)
+			}
+		}
+		for _, path := range test.excluded {
+			if requested[path] {
+				t.Errorf("%s: expected %s not to be fetched", test.name, path)
+			}
+		}
+	}
+}
+
+func TestMirrorSkipsNonWebLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="mailto:someone@example.com">mail</a><a href="javascript:void(0)">js</a>` +
+			`<a href="tel:+15551234567">call</a><img src="data:image/png;base64,AAAA"><a href="/page.html">page</a>`))
+	}))
+	defer server.Close()
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	app := newWgetState()
+	app.urlArgs.spanHosts = true
+	if err := app.mirror(server.URL+"/", false); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if !app.visitedAssets[server.URL+"/page.html"] {
+		t.Errorf("Expected %s/page.html to be downloaded", server.URL)
+	}
+	for link := range app.visitedAssets {
+		if !strings.HasPrefix(link, "http") {
+			t.Errorf("Expected only web links to be followed, but got %q", link)
+		}
+	}
+	for page := range app.pageDepths {
+		if !strings.HasPrefix(page, "http") {
+			t.Errorf("Expected only web pages to be crawled, but got %q", page)
+		}
+	}
+}
+
+func TestMirrorNoParent(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		if r.URL.Path == "/docs/v2/" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="guide.html">guide</a><a href="../v1/">v1</a><a href="/blog/">blog</a>` +
+				`<a href="%2e%2e/v1/old.html">old</a><img src="/static/logo.png">`))
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	app := newWgetState()
+	app.urlArgs.noParent = true
+	if err := app.mirror(server.URL+"/docs/v2/", false); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	for _, path := range []string{"/docs/v2/guide.html", "/static/logo.png"} {
+		if !requested[path] {
+			t.Errorf("Expected %s to be fetched", path)
+		}
+	}
+	for _, path := range []string{"/docs/v1/", "/docs/v1/index.html", "/blog/index.html", "/docs/v2/../v1/old.html"} {
+		if requested[path] {
+			t.Errorf("Expected %s not to be fetched", path)
+		}
+	}
+}
+
+func TestPageRequisites(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/articles/post.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<link rel="stylesheet" href="../css/site.css"><script src="/js/app.js"></script>` +
+				`<img src="photo.jpg" srcset="photo-2x.jpg 2x"><video poster="/media/poster.jpg" src="/media/clip.mp4"></video>` +
+				`<a href="/articles/next.html">next</a><link rel="next" href="/articles/page-2.html">` +
+				`<link rel="alternate" type="application/rss+xml" href="/feed.xml"><link rel="icon" href="/favicon.ico">`))
+		case "/css/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte(`@import "print.css"; @font-face { src: url(../fonts/body.woff2); }`))
+		default:
+			w.Write([]byte("content"))
+		}
+	}))
+	defer server.Close()
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	app := newWgetState()
+	app.urlArgs.pageRequisites = true
+	if err := app.pageRequisites(server.URL+"/articles/post.html", false); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	for _, path := range []string{"/css/site.css", "/css/print.css", "/fonts/body.woff2", "/js/app.js",
+		"/articles/photo.jpg", "/articles/photo-2x.jpg", "/media/poster.jpg", "/media/clip.mp4", "/favicon.ico"} {
+		if !requested[path] {
+			t.Errorf("Expected %s to be fetched", path)
+		}
+	}
+	for _, path := range []string{"/articles/next.html", "/articles/page-2.html", "/feed.xml"} {
+		if requested[path] {
+			t.Errorf("Expected %s, a link to another document, not to be fetched", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("127.0.0.1", "articles", "post.html")); err != nil {
+		t.Errorf("Expected the page itself to be saved, but got %v", err)
+	}
+}
+
+func TestPageRequisitesConvertLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/articles/post.html" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<img src="http://` + r.Host + `/articles/photo.jpg">` +
+				`<a href="next.html">next</a><a href="#comments">comments</a>`))
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	app := newWgetState()
+	app.urlArgs.pageRequisites = true
+	if err := app.pageRequisites(server.URL+"/articles/post.html", true); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join("127.0.0.1", "articles", "post.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	// Anchors were not downloaded, so they keep pointing at the server
+	for _, expected := range []string{`href="` + server.URL + `/articles/next.html"`, `href="#comments"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the converted page to contain %s, but got %s", expected, page)
+		}
+	}
+	if strings.Contains(page, `src="http`) {
+		t.Errorf("Expected the image to point at its local copy, but got %s", page)
+	}
+}
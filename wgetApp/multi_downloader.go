@@ -5,22 +5,29 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
+// downloadResult records the outcome of one URL from an input file.
+type downloadResult struct {
+	url string
+	err error
+}
+
 /*
 downloadMultipleFiles
 *parameters*
 - filePath: The path to the file containing URLs (one per line).
 - outputFile: The output file where downloaded content is stored.
-- limit: A concurrency limit for the number of simultaneous downloads.
+- limit: The rate limit applied to each download.
 - directory: The directory where files should be saved.
 
 *functionality*
 - Opens the file containing the URLs.
 - Reads URLs line by line, skipping empty lines.
-- Uses a WaitGroup to manage multiple concurrent downloads.
--  Calls AsyncDownload to handle each URL download asynchronously.
-- Waits for all goroutines to complete before returning.
+- Feeds the URLs to a pool of --parallel workers that call singleDownloader.
+- Keeps going when a URL fails, so one bad link does not abort the batch.
+- Prints a per-URL summary and returns an error if any download failed.
 */
 func (app *WgetApp) downloadMultipleFiles(filePath, outputFile, limit, directory string) error {
 	file, err := os.Open(filePath)
@@ -29,21 +36,72 @@ func (app *WgetApp) downloadMultipleFiles(filePath, outputFile, limit, directory
 	}
 	defer file.Close()
 
+	var urls []string
 	scanner := bufio.NewScanner(file)
-
 	for scanner.Scan() {
 		url := strings.TrimSpace(scanner.Text())
 
 		if url == "" {
 			continue // Skip empty lines
 		}
+		urls = append(urls, url)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file:\n%v", err)
+	}
+
+	workers := app.urlArgs.parallel
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+	// Progress bars from concurrent downloads would overwrite each other
+	if workers > 1 {
+		app.hideProgress = true
+	}
 
-		// Process each URL synchronously (one after the other)
-		err := app.singleDownloader(outputFile, url, limit, directory)
-		if err != nil {
-			return err
+	results := make([]downloadResult, len(urls))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := app.singleDownloader(outputFile, urls[i], limit, directory)
+				results[i] = downloadResult{url: urls[i], err: err}
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return printSummary(results)
+}
+
+// printSummary lists the outcome of every URL and returns an error when any of them failed.
+func printSummary(results []downloadResult) error {
+	failed := 0
+	fmt.Println("Download summary:")
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Printf("  [failed] %s\n           %s\n", result.url, strings.ReplaceAll(result.err.Error(), "\n", " "))
+		} else {
+			fmt.Printf("  [ok]     %s\n", result.url)
 		}
 	}
+	fmt.Printf("%d succeeded, %d failed\n", len(results)-failed, failed)
 
+	if failed > 0 {
+		return fmt.Errorf("error: %d of %d downloads failed", failed, len(results))
+	}
 	return nil
 }
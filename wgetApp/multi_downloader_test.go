@@ -0,0 +1,103 @@
+package wgetApp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloadMultipleFilesContinuesAfterFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("content of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "urls.txt")
+	urls := []string{
+		server.URL + "/one.txt",
+		server.URL + "/missing.txt",
+		"",
+		server.URL + "/two.txt",
+		server.URL + "/three.txt",
+	}
+	if err := os.WriteFile(inputFile, []byte(strings.Join(urls, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.parallel = 3
+
+	err := app.downloadMultipleFiles(inputFile, "", "", tempDir)
+	if err == nil {
+		t.Errorf("Expected an error reporting the failed download, got nil")
+	}
+
+	for _, name := range []string{"one.txt", "two.txt", "three.txt"} {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Errorf("Expected %s to be downloaded despite the failure, got %v", name, err)
+			continue
+		}
+		if string(data) != "content of /"+name {
+			t.Errorf("Content mismatch for %s: got %q", name, string(data))
+		}
+	}
+}
+
+func TestDownloadMultipleFilesNumbersSharedNames(t *testing.T) {
+	// Hold both responses until both requests are in, so the downloads overlap
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		done := make(chan struct{})
+		go func() { arrived.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+		}
+		w.Write([]byte("content of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "urls.txt")
+	urls := server.URL + "/a/data.txt\n" + server.URL + "/b/data.txt\n"
+	if err := os.WriteFile(inputFile, []byte(urls), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.parallel = 2
+
+	if err := app.downloadMultipleFiles(inputFile, "", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var contents []string
+	for _, name := range []string{"data.txt", "data.txt.1"} {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Expected %s to be saved, got %v", name, err)
+		}
+		contents = append(contents, string(data))
+	}
+	sort.Strings(contents)
+	expected := []string{"content of /a/data.txt", "content of /b/data.txt"}
+	if contents[0] != expected[0] || contents[1] != expected[1] {
+		t.Errorf("Expected %q but got %q", expected, contents)
+	}
+}
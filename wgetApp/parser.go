@@ -2,8 +2,12 @@ package wgetApp
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
 	wgetutils "wget/wgetUtils"
 )
 
@@ -11,6 +15,7 @@ import (
 func (app *WgetApp) parser() error {
 	mirrorMode := false // Flag to track if --mirror is used
 	track := false      // Flag to track if a source file is provided (-i=)
+	bodyData := false   // Flag to track if --body-data is used, which needs --method
 
 	for _, arg := range os.Args[1:] {
 		if strings.HasPrefix(arg, "-O=") {
@@ -25,29 +30,302 @@ func (app *WgetApp) parser() error {
 		} else if strings.HasPrefix(arg, "--mirror") {
 			app.urlArgs.mirroring = true
 			mirrorMode = true
+		} else if arg == "-p" || arg == "--page-requisites" {
+			app.urlArgs.pageRequisites = true
 		} else if strings.HasPrefix(arg, "--convert-links") {
-			if !mirrorMode {
-				return fmt.Errorf("error: --convert-links can only be used with --mirror")
+			if !mirrorMode && !app.urlArgs.pageRequisites {
+				return fmt.Errorf("error: --convert-links can only be used with --mirror or --page-requisites")
 			}
 			app.urlArgs.convertLinksFlag = true
 		} else if strings.HasPrefix(arg, "-R=") || strings.HasPrefix(arg, "--reject=") {
 			if !mirrorMode {
 				return fmt.Errorf("error: --reject can only be used with --mirror")
 			}
-			if strings.HasPrefix(arg, "-R=") {
-				app.urlArgs.rejectFlag = arg[len("-R="):]
-			} else {
-				app.urlArgs.rejectFlag = arg[len("--reject="):]
+			app.urlArgs.filter.Reject = arg[strings.Index(arg, "=")+1:]
+		} else if strings.HasPrefix(arg, "-A=") || strings.HasPrefix(arg, "--accept=") {
+			if !mirrorMode {
+				return fmt.Errorf("error: --accept can only be used with --mirror")
 			}
-		} else if strings.HasPrefix(arg, "-X=") || strings.HasPrefix(arg, "--exclude=") {
+			app.urlArgs.filter.Accept = arg[strings.Index(arg, "=")+1:]
+		} else if strings.HasPrefix(arg, "-X=") || strings.HasPrefix(arg, "--exclude=") || strings.HasPrefix(arg, "--exclude-directories=") {
 			if !mirrorMode {
 				return fmt.Errorf("error: --exclude can only be used with --mirror")
 			}
-			if strings.HasPrefix(arg, "-X=") {
-				app.urlArgs.excludeFlag = arg[len("-X="):]
+			app.urlArgs.filter.ExcludeDirs = arg[strings.Index(arg, "=")+1:]
+		} else if strings.HasPrefix(arg, "-I=") || strings.HasPrefix(arg, "--include-directories=") {
+			if !mirrorMode {
+				return fmt.Errorf("error: --include-directories can only be used with --mirror")
+			}
+			app.urlArgs.filter.IncludeDirs = arg[strings.Index(arg, "=")+1:]
+		} else if strings.HasPrefix(arg, "--accept-regex=") {
+			if !mirrorMode {
+				return fmt.Errorf("error: --accept-regex can only be used with --mirror")
+			}
+			app.urlArgs.filter.AcceptRegex = arg[len("--accept-regex="):]
+		} else if strings.HasPrefix(arg, "--reject-regex=") {
+			if !mirrorMode {
+				return fmt.Errorf("error: --reject-regex can only be used with --mirror")
+			}
+			app.urlArgs.filter.RejectRegex = arg[len("--reject-regex="):]
+		} else if arg == "--ignore-case" {
+			if !mirrorMode {
+				return fmt.Errorf("error: --ignore-case can only be used with --mirror")
+			}
+			app.urlArgs.filter.IgnoreCase = true
+		} else if arg == "-np" || arg == "--no-parent" {
+			if !mirrorMode {
+				return fmt.Errorf("error: --no-parent can only be used with --mirror")
+			}
+			app.urlArgs.noParent = true
+		} else if arg == "-H" || arg == "--span-hosts" {
+			if !mirrorMode && !app.urlArgs.pageRequisites {
+				return fmt.Errorf("error: --span-hosts can only be used with --mirror or --page-requisites")
+			}
+			app.urlArgs.spanHosts = true
+		} else if arg == "--span-requisites" {
+			if !mirrorMode && !app.urlArgs.pageRequisites {
+				return fmt.Errorf("error: --span-requisites can only be used with --mirror or --page-requisites")
+			}
+			app.urlArgs.spanRequisites = true
+		} else if strings.HasPrefix(arg, "-D=") || strings.HasPrefix(arg, "--domains=") {
+			app.urlArgs.domains.Domains = wgetutils.ParseDomainList(arg[strings.Index(arg, "=")+1:])
+			if len(app.urlArgs.domains.Domains) == 0 {
+				return fmt.Errorf("invalid --domains value.\nUsage: --domains=example.com,example.org")
+			}
+		} else if strings.HasPrefix(arg, "--exclude-domains=") {
+			app.urlArgs.domains.Exclude = wgetutils.ParseDomainList(arg[len("--exclude-domains="):])
+			if len(app.urlArgs.domains.Exclude) == 0 {
+				return fmt.Errorf("invalid --exclude-domains value.\nUsage: --exclude-domains=ads.example.com")
+			}
+		} else if arg == "-c" || arg == "--continue" {
+			app.urlArgs.continueDownload = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "-l=") || strings.HasPrefix(arg, "--level=") {
+			if !mirrorMode {
+				return fmt.Errorf("error: --level can only be used with --mirror")
+			}
+			level, err := wgetutils.ParseLevel(arg[strings.Index(arg, "=")+1:])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.level = level
+		} else if strings.HasPrefix(arg, "-e=") || strings.HasPrefix(arg, "--execute=") {
+			command := strings.ToLower(strings.ReplaceAll(arg[strings.Index(arg, "=")+1:], " ", ""))
+			switch command {
+			case "robots=off":
+				app.urlArgs.ignoreRobots = true
+			case "robots=on":
+				app.urlArgs.ignoreRobots = false
+			default:
+				return fmt.Errorf("unsupported -e command %q.\nUsage: -e=robots=off", arg[strings.Index(arg, "=")+1:])
+			}
+		} else if strings.HasPrefix(arg, "--segments=") {
+			segments, err := strconv.Atoi(arg[len("--segments="):])
+			if err != nil || segments < 1 {
+				return fmt.Errorf("invalid segments value.\nUsage: --segments=4")
+			}
+			app.urlArgs.segments = segments
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--parallel=") {
+			parallel, err := strconv.Atoi(arg[len("--parallel="):])
+			if err != nil || parallel < 1 {
+				return fmt.Errorf("invalid parallel value.\nUsage: --parallel=4")
+			}
+			app.urlArgs.parallel = parallel
+		} else if strings.HasPrefix(arg, "--tries=") {
+			tries, err := wgetutils.ParseTries(arg[len("--tries="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.retry.Tries = tries
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--waitretry=") {
+			seconds, err := strconv.Atoi(arg[len("--waitretry="):])
+			if err != nil || seconds < 0 {
+				return fmt.Errorf("invalid waitretry value.\nUsage: --waitretry=10")
+			}
+			app.urlArgs.retry.WaitRetry = time.Duration(seconds) * time.Second
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--retry-on-http-error=") {
+			codes, err := wgetutils.ParseStatusList(arg[len("--retry-on-http-error="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.retry.RetryOn = codes
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--connect-timeout=") {
+			timeout, err := wgetutils.ParseSeconds("--connect-timeout", arg[len("--connect-timeout="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.client.ConnectTimeout = timeout
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--read-timeout=") {
+			timeout, err := wgetutils.ParseSeconds("--read-timeout", arg[len("--read-timeout="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.client.ReadTimeout = timeout
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--timeout=") {
+			timeout, err := wgetutils.ParseSeconds("--timeout", arg[len("--timeout="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.client.Timeout = timeout
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--header=") {
+			name, value, err := wgetutils.ParseHeader(arg[len("--header="):])
+			if err != nil {
+				return err
+			}
+			if app.urlArgs.client.Headers == nil {
+				app.urlArgs.client.Headers = http.Header{}
+			}
+			app.urlArgs.client.Headers[name] = append(app.urlArgs.client.Headers[name], value)
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "-U=") || strings.HasPrefix(arg, "--user-agent=") {
+			if strings.HasPrefix(arg, "-U=") {
+				app.urlArgs.client.UserAgent = arg[len("-U="):]
+			} else {
+				app.urlArgs.client.UserAgent = arg[len("--user-agent="):]
+			}
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--load-cookies=") {
+			app.urlArgs.loadCookies = arg[len("--load-cookies="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--save-cookies=") {
+			app.urlArgs.saveCookies = arg[len("--save-cookies="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--keep-session-cookies" {
+			app.urlArgs.keepSessionCookies = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--user=") {
+			app.urlArgs.client.User = arg[len("--user="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--password=") {
+			// Not forwarded: background downloads receive it through the environment
+			app.urlArgs.client.Password = arg[len("--password="):]
+		} else if arg == "--ask-password" {
+			app.urlArgs.askPassword = true
+		} else if arg == "--auth-no-challenge" {
+			app.urlArgs.client.AuthNoChallenge = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--netrc-file=") {
+			app.urlArgs.netrcFile = arg[len("--netrc-file="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--proxy=") {
+			if _, err := wgetutils.ParseProxy(arg[len("--proxy="):]); err != nil {
+				return err
+			}
+			app.urlArgs.client.Proxy = arg[len("--proxy="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--no-proxy" {
+			app.urlArgs.client.NoProxy = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--proxy-user=") {
+			app.urlArgs.client.ProxyUser = arg[len("--proxy-user="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--proxy-password=") {
+			// Not forwarded: background downloads receive it through the environment
+			app.urlArgs.client.ProxyPassword = arg[len("--proxy-password="):]
+		} else if strings.HasPrefix(arg, "--ca-certificate=") {
+			app.urlArgs.client.CACertificate = arg[len("--ca-certificate="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--ca-directory=") {
+			app.urlArgs.client.CADirectory = arg[len("--ca-directory="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--certificate=") {
+			app.urlArgs.client.Certificate = arg[len("--certificate="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--private-key=") {
+			app.urlArgs.client.PrivateKey = arg[len("--private-key="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--no-check-certificate" {
+			app.urlArgs.client.NoCheckCertificate = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--secure-protocol=") {
+			if _, err := wgetutils.ParseSecureProtocol(arg[len("--secure-protocol="):]); err != nil {
+				return err
+			}
+			app.urlArgs.client.SecureProtocol = arg[len("--secure-protocol="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--method=") {
+			app.urlArgs.method = strings.ToUpper(arg[len("--method="):])
+			if app.urlArgs.method == "" {
+				return fmt.Errorf("invalid --method value.\nUsage: --method=POST")
+			}
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--post-data=") || strings.HasPrefix(arg, "--body-data=") || strings.HasPrefix(arg, "--post-file=") {
+			if app.urlArgs.body != nil {
+				return fmt.Errorf("error: only one of --post-data, --post-file and --body-data can be used")
+			}
+			if strings.HasPrefix(arg, "--post-file=") {
+				data, err := os.ReadFile(arg[len("--post-file="):])
+				if err != nil {
+					return fmt.Errorf("error reading post file:\n%v", err)
+				}
+				app.urlArgs.body = data
 			} else {
-				app.urlArgs.excludeFlag = arg[len("--exclude="):]
+				_, value, _ := strings.Cut(arg, "=")
+				app.urlArgs.body = []byte(value)
+				bodyData = strings.HasPrefix(arg, "--body-data=")
+			}
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "-N" || arg == "--timestamping" {
+			app.urlArgs.timestamping = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "-nc" || arg == "--no-clobber" {
+			app.urlArgs.noClobber = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--backups=") {
+			backups, err := wgetutils.ParseBackups(arg[len("--backups="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.backups = backups
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--checksum=") {
+			checksum, err := wgetutils.ParseChecksum(arg[len("--checksum="):])
+			if err != nil {
+				return err
+			}
+			app.urlArgs.checksum = checksum
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--checksum-from-sidecar" {
+			app.urlArgs.checksumSidecar = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--metalink=") {
+			app.urlArgs.metalinkFile = arg[len("--metalink="):]
+		} else if arg == "--metalink" {
+			app.urlArgs.metalinkAuto = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--max-redirect=") {
+			redirects, err := strconv.Atoi(arg[len("--max-redirect="):])
+			if err != nil || redirects < 0 {
+				return fmt.Errorf("invalid --max-redirect value.\nUsage: --max-redirect=20")
+			}
+			// Zero means "use the default" to the client, so store "none" as negative
+			if redirects == 0 {
+				redirects = -1
+			}
+			app.urlArgs.client.MaxRedirect = redirects
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--allow-insecure-redirects" {
+			app.urlArgs.client.AllowInsecureRedirects = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--trust-server-names" {
+			app.urlArgs.trustServerNames = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if strings.HasPrefix(arg, "--compression=") {
+			if err := wgetutils.ParseCompression(arg[len("--compression="):]); err != nil {
+				return err
 			}
+			app.urlArgs.client.Compression = arg[len("--compression="):]
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
+		} else if arg == "--content-disposition" {
+			app.urlArgs.contentDisposition = true
+			app.urlArgs.forwardArgs = append(app.urlArgs.forwardArgs, arg)
 		} else if strings.HasPrefix(arg, "-B") {
 			app.urlArgs.workInBackground = true
 		} else if strings.HasPrefix(arg, "-i=") {
@@ -60,6 +338,64 @@ func (app *WgetApp) parser() error {
 		}
 	}
 
+	// Credentials handed down by a background parent, or typed in without echo
+	if password := os.Getenv(passwordEnv); password != "" && app.urlArgs.client.Password == "" {
+		app.urlArgs.client.Password = password
+	}
+	if password := os.Getenv(proxyPasswordEnv); password != "" && app.urlArgs.client.ProxyPassword == "" {
+		app.urlArgs.client.ProxyPassword = password
+	}
+	if app.urlArgs.askPassword {
+		if app.urlArgs.client.User == "" {
+			return fmt.Errorf("error: --ask-password requires --user")
+		}
+		password, err := wgetutils.ReadPassword(fmt.Sprintf("Password for user '%s': ", app.urlArgs.client.User))
+		if err != nil {
+			return err
+		}
+		app.urlArgs.client.Password = password
+	}
+
+	// --post-data and --post-file imply POST, while --body-data goes with an explicit --method
+	if app.urlArgs.body != nil && app.urlArgs.method == "" {
+		if bodyData {
+			return fmt.Errorf("error: --body-data requires --method")
+		}
+		app.urlArgs.method = "POST"
+	}
+	if app.urlArgs.method != "" && (app.urlArgs.segments > 1 || app.urlArgs.continueDownload) {
+		return fmt.Errorf("error: --method and request bodies cannot be used with --segments or --continue")
+	}
+
+	// Digests are computed as the bytes stream in, which segments written out of order can't do
+	if app.urlArgs.checksum != nil || app.urlArgs.checksumSidecar ||
+		app.urlArgs.metalinkFile != "" || app.urlArgs.metalinkAuto {
+		if app.urlArgs.checksum != nil && app.urlArgs.checksumSidecar {
+			return fmt.Errorf("error: --checksum cannot be used with --checksum-from-sidecar")
+		}
+		if app.urlArgs.segments > 1 {
+			return fmt.Errorf("error: checksums cannot be verified with --segments")
+		}
+		if app.urlArgs.checksum != nil && app.urlArgs.sourceFile != "" {
+			return fmt.Errorf("error: --checksum applies to a single URL; use --checksum-from-sidecar with -i")
+		}
+	}
+
+	// A Metalink file names its own outputs and sources
+	if app.urlArgs.metalinkFile != "" {
+		if app.urlArgs.url != "" || app.urlArgs.sourceFile != "" || app.urlArgs.file != "" || app.urlArgs.workInBackground {
+			return fmt.Errorf("error: --metalink=FILE cannot be used with a URL, -i, -O or -B")
+		}
+	}
+
+	if app.urlArgs.noClobber && (app.urlArgs.timestamping || app.urlArgs.backups > 0) {
+		return fmt.Errorf("error: --no-clobber cannot be used with --timestamping or --backups")
+	}
+
+	if app.urlArgs.client.NoProxy && app.urlArgs.client.Proxy != "" {
+		return fmt.Errorf("error: --proxy cannot be used with --no-proxy")
+	}
+
 	// Validate background mode restrictions
 	if app.urlArgs.workInBackground {
 		if app.urlArgs.sourceFile != "" || app.urlArgs.path != "" {
@@ -67,29 +403,62 @@ func (app *WgetApp) parser() error {
 		}
 	}
 
+	// Segments share one rate budget poorly, so keep the two modes apart
+	if app.urlArgs.segments > 1 && app.urlArgs.rateLimit != "" {
+		return fmt.Errorf("error: --segments cannot be used with --rate-limit")
+	}
+
+	// Parallel workers only make sense for a list of URLs
+	if app.urlArgs.parallel > 0 && app.urlArgs.sourceFile == "" {
+		return fmt.Errorf("error: --parallel can only be used with -i")
+	}
+	if app.urlArgs.parallel > 1 && app.urlArgs.file != "" {
+		return fmt.Errorf("error: -O cannot be used with --parallel, the downloads would write to the same file")
+	}
+
+	// The domain lists only filter hosts other than the start host, so they need spanning
+	if (len(app.urlArgs.domains.Domains) > 0 || len(app.urlArgs.domains.Exclude) > 0) &&
+		!app.urlArgs.spanHosts && !app.urlArgs.spanRequisites {
+		return fmt.Errorf("error: --domains and --exclude-domains can only be used with --span-hosts or --span-requisites")
+	}
+
 	// Ensure --mirror is not combined with incompatible flags
 	if app.urlArgs.mirroring {
+		if app.urlArgs.pageRequisites {
+			return fmt.Errorf("error: --page-requisites cannot be used with --mirror, which already downloads page requisites")
+		}
+		if app.urlArgs.file != "" || app.urlArgs.path != "" || app.urlArgs.rateLimit != "" ||
+			app.urlArgs.sourceFile != "" || app.urlArgs.workInBackground || app.urlArgs.continueDownload || app.urlArgs.segments > 0 ||
+			app.urlArgs.method != "" || app.urlArgs.checksum != nil || app.urlArgs.checksumSidecar {
+			return fmt.Errorf("error: --mirror can only be used with --convert-links, URL filters, --level, --no-parent, -e, host spanning options, and a URL. No other flags are allowed")
+		}
+		if err := app.urlArgs.filter.Compile(); err != nil {
+			return err
+		}
+	} else if app.urlArgs.pageRequisites {
 		if app.urlArgs.file != "" || app.urlArgs.path != "" || app.urlArgs.rateLimit != "" ||
-			app.urlArgs.sourceFile != "" || app.urlArgs.workInBackground {
-			return fmt.Errorf("error: --mirror can only be used with --convert-links, --reject, --exclude, and a URL. No other flags are allowed")
+			app.urlArgs.sourceFile != "" || app.urlArgs.workInBackground || app.urlArgs.continueDownload || app.urlArgs.segments > 0 ||
+			app.urlArgs.method != "" || app.urlArgs.checksum != nil || app.urlArgs.checksumSidecar ||
+			app.urlArgs.metalinkFile != "" || app.urlArgs.metalinkAuto {
+			return fmt.Errorf("error: --page-requisites can only be used with --convert-links, host spanning options, -e, request options, and a URL")
 		}
 	} else {
 		// Ensure --convert-links, --reject, and --exclude are only used with --mirror
-		if app.urlArgs.convertLinksFlag || app.urlArgs.rejectFlag != "" || app.urlArgs.excludeFlag != "" {
+		if app.urlArgs.convertLinksFlag || app.urlArgs.filter.Reject != "" || app.urlArgs.filter.ExcludeDirs != "" {
 			return fmt.Errorf("error: --convert-links, --reject, and --exclude can only be used with --mirror")
 		}
 	}
 
-	// Ensure a URL or source file is provided for valid execution
-	if app.urlArgs.url == "" && !track {
+	// Ensure a URL, source file or Metalink file is provided for valid execution
+	if app.urlArgs.url == "" && !track && app.urlArgs.metalinkFile == "" {
 		return fmt.Errorf("error: URL not provided")
 	}
 
 	// Validate the url
 	err := wgetutils.ValidateURL(app.urlArgs.url)
-	if err != nil && app.urlArgs.sourceFile == "" {
+	if err != nil && app.urlArgs.sourceFile == "" && app.urlArgs.metalinkFile == "" {
 		return fmt.Errorf("error: invalid url provided")
 	}
 
-	return nil
+	return app.configureHTTP()
 }
@@ -0,0 +1,61 @@
+package wgetApp
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	wgetutils "wget/wgetUtils"
+)
+
+// robotsHost is the cached robots.txt of one host, and when it may next be requested
+// under its Crawl-delay.
+type robotsHost struct {
+	rules *wgetutils.Robots
+	next  time.Time
+}
+
+// allowCrawl reports whether robots.txt lets the mirror fetch rawURL, and waits out the
+// host's Crawl-delay before returning true. robots.txt is fetched once per host.
+func (app *WgetApp) allowCrawl(rawURL string) bool {
+	if app.urlArgs.ignoreRobots {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	key := u.Scheme + "://" + u.Host
+
+	// Holding the lock while fetching keeps concurrent links from fetching robots.txt twice
+	app.muRobots.Lock()
+	host, exists := app.robots[key]
+	if !exists {
+		rules, err := wgetutils.FetchRobots(rawURL)
+		if err != nil {
+			fmt.Printf("Could not read robots.txt for %s, crawling without it:\n%v\n", key, err)
+		}
+		host = &robotsHost{rules: rules}
+		app.robots[key] = host
+	}
+
+	if !host.rules.Allowed(u.RequestURI()) {
+		app.muRobots.Unlock()
+		fmt.Printf("Skipping %s (disallowed by robots.txt)\n", rawURL)
+		return false
+	}
+
+	// Reserve the next slot for this host, then sleep until it comes up
+	var wait time.Duration
+	if host.rules != nil && host.rules.CrawlDelay > 0 {
+		now := time.Now()
+		if host.next.After(now) {
+			wait = host.next.Sub(now)
+		}
+		host.next = now.Add(wait + host.rules.CrawlDelay)
+	}
+	app.muRobots.Unlock()
+
+	time.Sleep(wait)
+	return true
+}
@@ -0,0 +1,212 @@
+package wgetApp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	wgetutils "wget/wgetUtils"
+)
+
+// errNoRangeSupport signals that the server cannot serve byte ranges and the
+// file has to be fetched over a single connection instead.
+var errNoRangeSupport = errors.New("server does not support byte ranges")
+
+// segmentedDownload fetches the file at url into outputFile using the given number of
+// byte ranges, each downloaded on its own connection and written straight to its offset
+// in a preallocated file. It returns errNoRangeSupport when the server does not advertise
+// or honor range requests, so the caller can fall back to a single stream.
+//
+// claim is called once the server accepted the request. It applies the clobber policy and
+// returns the path to save to, or "" when the existing file has to be kept.
+//
+// The segments are written to outputFile.part, which only replaces outputFile once every
+// segment arrived. A failed run must not leave a full-size file with holes, since -c
+// would take it for a complete download.
+func (app *WgetApp) segmentedDownload(outputFile, url string, segments int, toDisplay bool, claim func() (string, error)) error {
+	head, err := wgetutils.HttpHeadRequest(url)
+	if err != nil {
+		return fmt.Errorf("error downloading file:\n%v", err)
+	}
+	head.Body.Close()
+
+	if head.StatusCode != http.StatusOK || head.Header.Get("Accept-Ranges") != "bytes" || head.ContentLength <= 0 {
+		return errNoRangeSupport
+	}
+	fmt.Printf("sending request, awaiting response... status %s\n", head.Status)
+
+	if outputFile, err = claim(); outputFile == "" {
+		return err
+	}
+
+	size := head.ContentLength
+	if int64(segments) > size {
+		segments = int(size)
+	}
+	fmt.Printf("content size: %d bytes [~%.2fMB]\n", size, float64(size)/1000000)
+	fmt.Printf("saving file to: %s\n", outputFile)
+	fmt.Printf("downloading in %d segments\n", segments)
+
+	partFile := outputFile + ".part"
+	out, err := os.Create(partFile)
+	if err != nil {
+		return fmt.Errorf("error creating file:\n%v", err)
+	}
+	complete := false
+	defer func() {
+		out.Close()
+		if !complete {
+			os.Remove(partFile)
+		}
+	}()
+
+	// Preallocate the file so every segment can write at its own offset
+	if err := out.Truncate(size); err != nil {
+		return fmt.Errorf("error allocating file:\n%v", err)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		downloaded atomic.Int64
+		failed     atomic.Bool
+		errMu      sync.Mutex
+		firstErr   error
+	)
+	fail := func(err error) {
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		errMu.Unlock()
+		failed.Store(true)
+	}
+
+	startDownload := time.Now()
+	segmentSize := size / int64(segments)
+	for i := 0; i < segments; i++ {
+		start := int64(i) * segmentSize
+		end := start + segmentSize - 1
+		if i == segments-1 {
+			end = size - 1
+		}
+
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			if err := fetchSegment(out, url, start, end, &downloaded, &failed); err != nil {
+				fail(err)
+			}
+		}(start, end)
+	}
+
+	// Combine the progress of all segments into the single progress bar
+	done := make(chan struct{})
+	if toDisplay {
+		fmt.Print("Downloading... ")
+		go func() {
+			ticker := time.NewTicker(200 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					printProgress(downloaded.Load(), 0, size, startDownload)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+
+	if firstErr != nil {
+		if toDisplay {
+			fmt.Println()
+		}
+		return firstErr
+	}
+	if downloaded.Load() != size {
+		return fmt.Errorf("error: downloaded %d of %d bytes\nurl: [%s]", downloaded.Load(), size, url)
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("error writing to file\n%v", err)
+	}
+	if err := os.Rename(partFile, outputFile); err != nil {
+		return fmt.Errorf("error saving file:\n%v", err)
+	}
+	complete = true
+
+	if toDisplay {
+		printProgress(size, 0, size, startDownload)
+		fmt.Println()
+		fmt.Println()
+	}
+
+	if app.urlArgs.timestamping {
+		return wgetutils.ApplyLastModified(outputFile, head.Header)
+	}
+	return nil
+}
+
+// fetchSegment downloads the inclusive byte range start-end of url and writes it to out
+// at the matching offset. It stops early once another segment has failed.
+func fetchSegment(out *os.File, url string, start, end int64, downloaded *atomic.Int64, failed *atomic.Bool) error {
+	resp, err := wgetutils.HttpRequestWithHeaders(url, map[string]string{
+		"Range": fmt.Sprintf("bytes=%d-%d", start, end),
+	})
+	if err != nil {
+		return fmt.Errorf("error downloading segment %d-%d:\n%v", start, end, err)
+	}
+	defer resp.Body.Close()
+
+	// A 200 means the server ignored the range and is sending the whole file
+	if resp.StatusCode == http.StatusOK {
+		return errNoRangeSupport
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("error: status %s\nurl: [%s]", resp.Status, url)
+	}
+	if rangeStart, _, err := wgetutils.ParseContentRange(resp.Header.Get("Content-Range")); err != nil || rangeStart != start {
+		return errNoRangeSupport
+	}
+
+	buffer := make([]byte, 32*1024) // 32 KB buffer size
+	pos := start
+	for pos <= end {
+		if failed.Load() {
+			return nil
+		}
+
+		n, err := resp.Body.Read(buffer)
+		if n > 0 {
+			// Never write past the end of this segment, even if the server sends extra bytes
+			if remaining := end - pos + 1; int64(n) > remaining {
+				n = int(remaining)
+			}
+			if _, err := out.WriteAt(buffer[:n], pos); err != nil {
+				return fmt.Errorf("error writing to file\n%v", err)
+			}
+			pos += int64(n)
+			downloaded.Add(int64(n))
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading response body\n%v", err)
+		}
+	}
+
+	if pos <= end {
+		return fmt.Errorf("error: segment %d-%d ended early at byte %d\nurl: [%s]", start, end, pos, url)
+	}
+	return nil
+}
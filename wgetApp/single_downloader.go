@@ -1,18 +1,26 @@
 package wgetApp
 
 import (
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	wgetutils "wget/wgetUtils"
 )
 
 func (app *WgetApp) singleDownloader(file, url, limit, directory string) error {
+	return app.downloadFile(file, url, limit, directory, nil)
+}
+
+// downloadFile does the work of singleDownloader. When meta is set, the file is one entry
+// of a Metalink document: it is verified against the listed size and digest, and its
+// response is never treated as another Metalink document.
+func (app *WgetApp) downloadFile(file, url, limit, directory string, meta *wgetutils.MetalinkFile) error {
 	path, err := wgetutils.ExpandPath(directory)
 	if err != nil {
 		return err
@@ -23,38 +31,201 @@ func (app *WgetApp) singleDownloader(file, url, limit, directory string) error {
 	if err != nil {
 		return err
 	}
+	toDisplay = toDisplay && !app.hideProgress
 	fmt.Printf("started at %s\n", startTime.Format("2006-01-02 15:04:05"))
 
-	resp, err := wgetutils.HttpRequest(fileURL)
+	// A name given with -O is overwritten, while a derived name is numbered when taken
+	named := file != ""
+
+	// Set the output file name. With --content-disposition or --trust-server-names it
+	// normally comes from the response, but resuming, segmenting and timestamping need it
+	// up front, so ask with a HEAD request.
+	serverNamed := app.urlArgs.contentDisposition || app.urlArgs.trustServerNames
+	if file == "" && serverNamed && (app.urlArgs.continueDownload || app.urlArgs.segments > 1 || app.urlArgs.timestamping) {
+		if head, err := wgetutils.HttpHeadRequest(fileURL); err == nil {
+			head.Body.Close()
+			file = app.responseFileName(head, fileURL)
+		} else {
+			// Without a name the checks below would look at the directory itself
+			file = wgetutils.FileNameFromURL(fileURL)
+		}
+	}
+	if file == "" && !serverNamed {
+		file = wgetutils.FileNameFromURL(fileURL)
+	}
+	outputFile := filepath.Join(path, file)
+
+	if path != "" {
+		err = os.MkdirAll(path, 0o755)
+		if err != nil {
+			return fmt.Errorf("oops! error creating path\n%v", err)
+		}
+	}
+
+	// When resuming, only ask the server for the bytes we don't have yet
+	var offset int64
+	headers := map[string]string{}
+	if app.urlArgs.continueDownload {
+		if info, err := os.Stat(outputFile); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		}
+	}
+
+	// With -N, let the server tell us whether our copy is still current
+	if app.urlArgs.timestamping && offset == 0 {
+		if since := wgetutils.IfModifiedSince(outputFile); since != "" {
+			headers["If-Modified-Since"] = since
+		}
+	}
+
+	// Apply the clobber policy once the server has accepted the request, so a failing URL
+	// leaves the existing file and its backups alone. With -N an existing file is only
+	// replaced once the server says it changed.
+	claimed, skipped := false, false
+	claim := func() (bool, error) {
+		claimed = true
+		target, err := app.claimOutput(outputFile, named)
+		if err != nil {
+			return false, err
+		}
+		if target == "" {
+			fmt.Printf("file %s already exists; not retrieving.\n\n", outputFile)
+			skipped = true
+			return false, nil
+		}
+		outputFile, file = target, filepath.Base(target)
+		return true, nil
+	}
+	defer func() {
+		if claimed && !skipped {
+			app.releaseOutput(outputFile)
+		}
+	}()
+	// A partial file being resumed is our own target, even if the server restarts it
+	if offset > 0 {
+		claimed = true
+	}
+	// -nc keeps an existing file whatever the server says, so don't even ask
+	if app.urlArgs.noClobber && offset == 0 && file != "" && wgetutils.FileExists(outputFile) {
+		fmt.Printf("file %s already exists; not retrieving.\n\n", outputFile)
+		return nil
+	}
+
+	// Split large files into parallel byte ranges when requested. A conditional request
+	// is answered in one response, so a -N refresh of an existing file uses one stream.
+	if app.urlArgs.segments > 1 && offset == 0 && headers["If-Modified-Since"] == "" {
+		err := app.segmentedDownload(outputFile, fileURL, app.urlArgs.segments, toDisplay, func() (string, error) {
+			if ok, err := claim(); !ok {
+				return "", err
+			}
+			return outputFile, nil
+		})
+		if err == nil {
+			if !skipped {
+				printFinished(fileURL, toDisplay)
+			}
+			return nil
+		}
+		if !errors.Is(err, errNoRangeSupport) {
+			return err
+		}
+		fmt.Println("server does not accept byte ranges, falling back to a single connection")
+	}
+
+	// Know the expected digest before spending time on the download
+	checksum := app.urlArgs.checksum
+	if meta != nil {
+		if checksum, err = meta.Checksum(); err != nil {
+			return err
+		}
+	} else if app.urlArgs.checksumSidecar {
+		if checksum, err = wgetutils.FetchSidecarChecksum(fileURL); err != nil {
+			return err
+		}
+	}
+
+	resp, err := app.request(fileURL, headers)
 	if err != nil {
-		return fmt.Errorf("error downloading file:\nserver misbehaving")
+		return fmt.Errorf("error downloading file:\n%v", err)
+	}
+	// resp is replaced when a dropped connection is resumed, so close whichever is current
+	defer func() { resp.Body.Close() }()
+
+	if headers["If-Modified-Since"] != "" && wgetutils.IsUpToDate(resp, outputFile) {
+		fmt.Printf("server file no newer than local file %s, not retrieving\n\n", outputFile)
+		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if offset > 0 {
+			fmt.Println("server does not support resuming, restarting download from the beginning")
+			offset = 0
+		}
+	case http.StatusPartialContent:
+		start, _, err := wgetutils.ParseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return fmt.Errorf("error resuming download:\n%v", err)
+		}
+		if start != offset {
+			return fmt.Errorf("error resuming download: server resumed at byte %d instead of %d\nurl: [%s]", start, offset, url)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			fmt.Printf("the file is already fully retrieved; nothing to do.\n\n")
+			if checksum != nil {
+				digest := checksum.NewHash()
+				if err := wgetutils.HashFile(digest, outputFile); err != nil {
+					return err
+				}
+				return verifyChecksum(checksum, digest.Sum(nil), outputFile)
+			}
+			return nil
+		}
+		return fmt.Errorf("error: status %s\nurl: [%s]", resp.Status, url)
+	default:
 		return fmt.Errorf("error: status %s\nurl: [%s]", resp.Status, url)
 	}
 	fmt.Printf("sending request, awaiting response... status %s\n", resp.Status)
 
-	contentLength := resp.ContentLength
-	fmt.Printf("content size: %d bytes [~%.2fMB]\n", contentLength, float64(contentLength)/1000000)
+	// With --metalink, a Metalink document is followed instead of being saved
+	if app.urlArgs.metalinkAuto && meta == nil && offset == 0 && wgetutils.IsMetalink(resp) {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxMetalinkSize))
+		if err != nil {
+			return fmt.Errorf("error reading metalink:\n%v", err)
+		}
+		metalink, err := wgetutils.ParseMetalink(data)
+		if err != nil {
+			return err
+		}
+		return app.downloadMetalink(metalink, limit, directory)
+	}
 
-	// Set the output file name
-	var outputFile string
 	if file == "" {
-		urlParts := strings.Split(fileURL, "/")
-		file = urlParts[len(urlParts)-1]
-		outputFile = filepath.Join(path, file)
-	} else {
+		file = app.responseFileName(resp, fileURL)
 		outputFile = filepath.Join(path, file)
 	}
-
-	if path != "" {
-		err = os.MkdirAll(path, 0o755)
-		if err != nil {
-			return fmt.Errorf("oops! error creating path\n%v", err)
+	if offset == 0 && !claimed {
+		if ok, err := claim(); !ok {
+			return err
 		}
 	}
+
+	// The total size includes the bytes already on disk when resuming
+	contentLength := resp.ContentLength
+	if contentLength >= 0 {
+		contentLength += offset
+	}
+	if decoded, ok := resp.Body.(*wgetutils.DecodedBody); ok {
+		fmt.Printf("content size: %d bytes %s-compressed [~%.2fMB]\n", decoded.EncodedLength, decoded.Encoding, float64(decoded.EncodedLength)/1000000)
+	} else {
+		fmt.Printf("content size: %d bytes [~%.2fMB]\n", contentLength, float64(contentLength)/1000000)
+	}
+	if offset > 0 {
+		fmt.Printf("resuming from byte %d [%d bytes remaining]\n", offset, contentLength-offset)
+	}
+
 	temp := ""
 	if file != "" && directory != "" {
 		fmt.Printf("saving file to: %s%s\n", directory, file)
@@ -66,75 +237,267 @@ func (app *WgetApp) singleDownloader(file, url, limit, directory string) error {
 		fmt.Printf("saving file to: %s%s\n", temp, file)
 	}
 
-	out, err := os.Create(outputFile)
+	var out *os.File
+	if offset > 0 {
+		out, err = os.OpenFile(outputFile, os.O_WRONLY|os.O_APPEND, 0o644)
+	} else {
+		out, err = os.Create(outputFile)
+	}
 	if err != nil {
 		return fmt.Errorf("error creating file:\n%v", err)
 	}
 	defer out.Close()
 
-	var reader io.Reader
-	if limit != "" {
-		reader = wgetutils.NewRateLimitedReader(resp.Body, limit)
-	} else {
-		reader = resp.Body
+	wrapBody := func(body io.Reader) io.Reader {
+		if limit != "" {
+			return wgetutils.NewRateLimitedReader(body, limit)
+		}
+		return body
+	}
+	reader := wrapBody(resp.Body)
+
+	// Hash the bytes as they are written, starting with any part already on disk
+	var writer io.Writer = out
+	var digest hash.Hash
+	if checksum != nil {
+		digest = checksum.NewHash()
+		if offset > 0 {
+			if err := wgetutils.HashFile(digest, outputFile); err != nil {
+				return err
+			}
+		}
+		writer = io.MultiWriter(out, digest)
 	}
 
 	buffer := make([]byte, 32*1024) // 32 KB buffer size
-	var downloaded int64
+	downloaded := offset
 	startDownload := time.Now()
+	policy := wgetutils.CurrentRetryPolicy()
+	attempt := 1
 
 	if toDisplay {
 		fmt.Print("Downloading... ")
 	}
 	for {
 		n, err := reader.Read(buffer)
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("error reading response body\n%v", err)
-		}
 
 		if n > 0 {
-			if _, err := out.Write(buffer[:n]); err != nil {
+			if _, err := writer.Write(buffer[:n]); err != nil {
 				return fmt.Errorf("error writing to file\n%v", err)
 			}
 			// Update the downloaded size
 			downloaded += int64(n)
 
 			if toDisplay {
-				// Calculate and display the progress
-				progress := float64(downloaded) / float64(contentLength) * 50
-				speed := float64(downloaded) / time.Since(startDownload).Seconds()
-				timeRemaining := time.Duration(float64(contentLength-downloaded)/speed) * time.Second
-
-				// Update the same line with progress
-				fmt.Printf("\r %.2f KiB / %.2f KiB [", float64(downloaded)/1024, float64(contentLength)/1024)
-				for i := 0; i < 50; i++ {
-					if i < int(progress) {
-						fmt.Print("=")
-					} else {
-						fmt.Print(" ")
-					}
-				}
-				fmt.Printf("] %.2f%% %.2f KiB/s %s", (float64(downloaded)*100)/float64(contentLength), speed/1024, timeRemaining.String())
-
+				current, total := wireProgress(resp.Body, downloaded, contentLength)
+				printProgress(current, offset, total, startDownload)
 			}
-
 		}
 
-		if downloaded >= contentLength {
+		if err == io.EOF || (contentLength >= 0 && downloaded >= contentLength) {
 			break
 		}
+		if err != nil {
+			if !policy.CanRetry(attempt) {
+				return fmt.Errorf("error reading response body\n%v", err)
+			}
+
+			// Pick up where the connection dropped instead of starting over
+			wait := policy.Backoff(attempt)
+			attempt++
+			fmt.Printf("\nconnection lost after %d bytes: %v\nretrying in %s (attempt %d)\n", downloaded, err, wait.Round(time.Millisecond), attempt)
+			time.Sleep(wait)
+			resp.Body.Close()
+
+			next, resumedAt, err := app.resumeFrom(fileURL, downloaded)
+			if err != nil {
+				return fmt.Errorf("error resuming download:\n%v", err)
+			}
+			resp = next
+			if resumedAt == 0 && downloaded > 0 {
+				fmt.Println("server does not support resuming, restarting download from the beginning")
+				if err := out.Truncate(0); err != nil {
+					return fmt.Errorf("error writing to file\n%v", err)
+				}
+				if _, err := out.Seek(0, io.SeekStart); err != nil {
+					return fmt.Errorf("error writing to file\n%v", err)
+				}
+				downloaded, offset = 0, 0
+				if digest != nil {
+					digest.Reset()
+				}
+				if resp.ContentLength >= 0 {
+					contentLength = resp.ContentLength
+				}
+			}
+			reader = wrapBody(resp.Body)
+		}
 	}
 	if toDisplay {
 		fmt.Println() // Move to the next line after download completes
 		fmt.Println()
 	}
 
+	if digest != nil {
+		out.Close()
+		if err := verifyChecksum(checksum, digest.Sum(nil), outputFile); err != nil {
+			return err
+		}
+	}
+
+	if meta != nil && meta.Size > 0 && downloaded != meta.Size {
+		out.Close()
+		os.Remove(outputFile)
+		return fmt.Errorf("error: size mismatch: expected %d bytes but got %d\ndeleted %s", meta.Size, downloaded, outputFile)
+	}
+
+	if app.urlArgs.timestamping {
+		if err := wgetutils.ApplyLastModified(outputFile, resp.Header); err != nil {
+			return err
+		}
+	}
+
+	printFinished(fileURL, toDisplay)
+	return nil
+}
+
+// wireProgress returns the byte counts to report progress with. A body decompressed on the
+// fly has no known decoded size, so its progress follows the compressed bytes instead.
+func wireProgress(body io.Reader, downloaded, contentLength int64) (int64, int64) {
+	if decoded, ok := body.(*wgetutils.DecodedBody); ok && decoded.EncodedLength > 0 {
+		return decoded.EncodedRead(), decoded.EncodedLength
+	}
+	return downloaded, contentLength
+}
+
+// resumeFrom re-requests url from byte offset after a dropped connection. It returns the
+// new response and the offset the server resumed from, which is 0 when the server ignored
+// the Range header and is sending the whole file again.
+func (app *WgetApp) resumeFrom(url string, offset int64) (*http.Response, int64, error) {
+	resp, err := app.request(url, map[string]string{
+		"Range": fmt.Sprintf("bytes=%d-", offset),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, 0, nil
+	case http.StatusPartialContent:
+		start, _, err := wgetutils.ParseContentRange(resp.Header.Get("Content-Range"))
+		if err == nil && start == offset {
+			return resp, offset, nil
+		}
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("server resumed at an unexpected position: %q", resp.Header.Get("Content-Range"))
+	default:
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("error: status %s\nurl: [%s]", resp.Status, url)
+	}
+}
+
+// verifyChecksum checks the digest of a finished download. A file that does not match is
+// deleted, so a corrupted or tampered download is never left behind looking complete.
+func verifyChecksum(checksum *wgetutils.Checksum, sum []byte, outputFile string) error {
+	if err := checksum.Verify(sum); err != nil {
+		if removeErr := os.Remove(outputFile); removeErr != nil {
+			return fmt.Errorf("error: %v\nfailed to delete %s: %v", err, outputFile, removeErr)
+		}
+		return fmt.Errorf("error: %v\ndeleted %s", err, outputFile)
+	}
+	fmt.Printf("checksum verified: %s:%x\n", checksum.Algorithm, sum)
+	return nil
+}
+
+// claimOutput applies the -nc and --backups policy to outputFile before a fresh download.
+// Names the user did not choose are numbered instead of overwritten, like wget does,
+// except with -N, whose whole point is replacing the local copy. It returns the path to
+// write to, or "" when the existing file has to be kept.
+//
+// The path is reserved until releaseOutput, so a concurrent --parallel download of the
+// same name sees it taken and picks the next number.
+func (app *WgetApp) claimOutput(outputFile string, named bool) (string, error) {
+	app.muOutput.Lock()
+	defer app.muOutput.Unlock()
+	policy := wgetutils.ClobberPolicy{
+		NoClobber: app.urlArgs.noClobber,
+		Backups:   app.urlArgs.backups,
+		Number:    !named && !app.urlArgs.timestamping,
+		Taken:     func(path string) bool { return app.outputs[path] },
+	}
+
+	target, err := policy.PrepareOutput(outputFile)
+	if err != nil || target == "" {
+		return target, err
+	}
+	app.outputs[target] = true
+	return target, nil
+}
+
+// releaseOutput drops the reservation claimOutput made for outputFile.
+func (app *WgetApp) releaseOutput(outputFile string) {
+	app.muOutput.Lock()
+	delete(app.outputs, outputFile)
+	app.muOutput.Unlock()
+}
+
+// responseFileName names a download after the server's response: its Content-Disposition
+// with --content-disposition, then its final URL after redirects with --trust-server-names,
+// and otherwise the URL that was asked for.
+func (app *WgetApp) responseFileName(resp *http.Response, url string) string {
+	if app.urlArgs.contentDisposition {
+		if name := wgetutils.FileNameFromHeader(resp.Header); name != "" {
+			return name
+		}
+	}
+	if app.urlArgs.trustServerNames && resp.Request != nil {
+		return wgetutils.FileNameFromURL(resp.Request.URL.String())
+	}
+	return wgetutils.FileNameFromURL(url)
+}
+
+// request fetches url with the --method and request body from the command line, or with
+// a plain GET when none were given.
+func (app *WgetApp) request(url string, headers map[string]string) (*http.Response, error) {
+	if app.urlArgs.method == "" {
+		return wgetutils.HttpRequestWithHeaders(url, headers)
+	}
+	return wgetutils.HttpRequestWithBody(app.urlArgs.method, url, app.urlArgs.body, headers)
+}
+
+// printFinished reports a completed download and the time it finished.
+func printFinished(fileURL string, toDisplay bool) {
 	endTime := time.Now()
 	fmt.Printf("Downloaded [%s]\n", fileURL)
 	fmt.Printf("finished at %s\n", endTime.Format("2006-01-02 15:04:05"))
 	if !toDisplay {
 		fmt.Println()
 	}
+}
 
-	return nil
+// printProgress redraws the progress bar on the current line. The offset is the number
+// of bytes that were already on disk before this transfer started, so the bar starts
+// from the resumed position while the speed only counts bytes fetched in this session.
+func printProgress(downloaded, offset, contentLength int64, startDownload time.Time) {
+	if contentLength <= 0 {
+		fmt.Printf("\r %.2f KiB", float64(downloaded)/1024)
+		return
+	}
+
+	// Calculate and display the progress
+	progress := float64(downloaded) / float64(contentLength) * 50
+	speed := float64(downloaded-offset) / time.Since(startDownload).Seconds()
+	timeRemaining := time.Duration(float64(contentLength-downloaded)/speed) * time.Second
+
+	// Update the same line with progress
+	fmt.Printf("\r %.2f KiB / %.2f KiB [", float64(downloaded)/1024, float64(contentLength)/1024)
+	for i := 0; i < 50; i++ {
+		if i < int(progress) {
+			fmt.Print("=")
+		} else {
+			fmt.Print(" ")
+		}
+	}
+	fmt.Printf("] %.2f%% %.2f KiB/s %s", (float64(downloaded)*100)/float64(contentLength), speed/1024, timeRemaining.String())
 }
@@ -0,0 +1,597 @@
+package wgetApp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	wgetutils "wget/wgetUtils"
+)
+
+func TestSingleDownloaderResume(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+
+	// http.ServeContent answers Range requests with 206 Partial Content
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.continueDownload = true
+
+	t.Run("Partial file is completed", func(t *testing.T) {
+		outputFile := filepath.Join(tempDir, "partial.bin")
+		if err := os.WriteFile(outputFile, []byte(content[:4000]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.singleDownloader("partial.bin", server.URL+"/data.bin", "", tempDir); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected resumed file of %d bytes, got %d bytes", len(content), len(data))
+		}
+	})
+
+	t.Run("Complete file is left alone", func(t *testing.T) {
+		outputFile := filepath.Join(tempDir, "complete.bin")
+		if err := os.WriteFile(outputFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.singleDownloader("complete.bin", server.URL+"/data.bin", "", tempDir); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected file to be unchanged, got %d bytes", len(data))
+		}
+	})
+
+	t.Run("Server without range support restarts", func(t *testing.T) {
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		}))
+		defer plain.Close()
+
+		outputFile := filepath.Join(tempDir, "restart.bin")
+		if err := os.WriteFile(outputFile, []byte("stale bytes"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.singleDownloader("restart.bin", plain.URL+"/data.bin", "", tempDir); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected file to be downloaded from scratch, got %d bytes", len(data))
+		}
+	})
+
+	t.Run("Restart reuses a derived name", func(t *testing.T) {
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		}))
+		defer plain.Close()
+
+		dir := filepath.Join(tempDir, "derived")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		outputFile := filepath.Join(dir, "data.bin")
+		if err := os.WriteFile(outputFile, []byte("stale bytes"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.singleDownloader("", plain.URL+"/data.bin", "", dir); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to be downloaded from scratch, got %d bytes", outputFile, len(data))
+		}
+		if _, err := os.Stat(outputFile + ".1"); !os.IsNotExist(err) {
+			t.Errorf("Expected no numbered copy, but got %s.1", outputFile)
+		}
+	})
+}
+
+func TestSingleDownloaderSegments(t *testing.T) {
+	content := strings.Repeat("abcdefghij", 5000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+	defer server.Close()
+
+	// A server that never advertises or honors ranges
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer plain.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.segments = 4
+
+	tests := []struct {
+		name string
+		url  string
+		file string
+	}{
+		{"Ranges supported", server.URL + "/data.bin", "segmented.bin"},
+		{"Ranges refused", plain.URL + "/data.bin", "fallback.bin"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := app.singleDownloader(test.file, test.url, "", tempDir); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tempDir, test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != content {
+				t.Errorf("Expected %d bytes matching the source, got %d bytes", len(content), len(data))
+			}
+		})
+	}
+}
+
+func TestSingleDownloaderSegmentFailureThenContinue(t *testing.T) {
+	content := strings.Repeat("abcdefghij", 100)
+	failing := true
+
+	// The second half fails on the first run, leaving nothing -c could mistake as complete
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing && r.Header.Get("Range") != "" && !strings.HasPrefix(r.Header.Get("Range"), "bytes=0-") {
+			http.Error(w, "segment unavailable", http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, "f.bin", time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.segments = 2
+
+	if err := app.singleDownloader("f.bin", server.URL+"/f.bin", "", tempDir); err == nil {
+		t.Fatalf("Expected the failed segment to be reported, got nil")
+	}
+	if info, err := os.Stat(filepath.Join(tempDir, "f.bin")); err == nil && info.Size() > 0 {
+		t.Errorf("Expected no partial f.bin after the failure, but got %d bytes", info.Size())
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "f.bin.part")); !os.IsNotExist(err) {
+		t.Errorf("Expected f.bin.part to be removed, but got %v", err)
+	}
+
+	failing = false
+	app.urlArgs.continueDownload = true
+	if err := app.singleDownloader("f.bin", server.URL+"/f.bin", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, "f.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected %d bytes matching the source, got %d bytes", len(content), len(data))
+	}
+}
+
+func TestSingleDownloaderRetryResumesPartialBody(t *testing.T) {
+	content := strings.Repeat("0123456789", 2000)
+	var ranges []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// Announce the full body, send half of it and drop the connection
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write([]byte(content[:len(content)/2]))
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+	defer server.Close()
+
+	defer wgetutils.SetRetryPolicy(wgetutils.CurrentRetryPolicy())
+	wgetutils.SetRetryPolicy(wgetutils.RetryPolicy{Tries: 3, WaitRetry: 10 * time.Millisecond})
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+
+	if err := app.singleDownloader("data.bin", server.URL+"/data.bin", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "data.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected %d bytes after the retry, got %d bytes", len(content), len(data))
+	}
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[1], "bytes=") || ranges[1] == "bytes=0-" {
+		t.Errorf("Expected the retry to resume with a Range request, got %q", ranges)
+	}
+}
+
+func TestSingleDownloaderContentDisposition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "42" {
+			w.Header().Set("Content-Disposition", `attachment; filename="fallback.csv"; filename*=UTF-8''r%C3%A9sum%C3%A9.csv`)
+		}
+		w.Write([]byte("a,b,c\n"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"RFC 5987 filename", server.URL + "/download?id=42", "résumé.csv"},
+		{"No header uses the URL", server.URL + "/download?id=7", "download"},
+		{"Directory URL", server.URL + "/reports/", "index.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			app := newWgetState()
+			app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+			app.urlArgs.contentDisposition = true
+
+			if err := app.singleDownloader("", test.url, "", tempDir); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(tempDir, test.expected)); err != nil {
+				t.Errorf("Expected file %s to be saved, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestSingleDownloaderServerNamedResumeWithoutHead(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+
+	// The HEAD request fails, so the name has to come from the URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.contentDisposition = true
+	app.urlArgs.continueDownload = true
+
+	outputFile := filepath.Join(tempDir, "data.bin")
+	if err := os.WriteFile(outputFile, []byte(content[:4000]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.singleDownloader("", server.URL+"/data.bin", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected resumed file of %d bytes, got %d bytes", len(content), len(data))
+	}
+}
+
+func TestSingleDownloaderTimestamping(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	content := "nightly build"
+	transfers := 0
+
+	// http.ServeContent answers If-Modified-Since with 304 Not Modified
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == "" {
+			transfers++
+		}
+		http.ServeContent(w, r, "build.txt", modified, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+	app.urlArgs.timestamping = true
+	outputFile := filepath.Join(tempDir, "build.txt")
+
+	if err := app.singleDownloader("", server.URL+"/build.txt", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	info, err := os.Stat(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("Expected mtime %v from Last-Modified, got %v", modified, info.ModTime())
+	}
+
+	// An unchanged file is not downloaded again
+	if err := app.singleDownloader("", server.URL+"/build.txt", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if transfers != 1 {
+		t.Errorf("Expected 1 unconditional request, got %d", transfers)
+	}
+
+	// A local copy older than the server's is replaced
+	if err := os.WriteFile(outputFile, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := modified.Add(-24 * time.Hour)
+	if err := os.Chtimes(outputFile, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.singleDownloader("", server.URL+"/build.txt", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected the stale copy to be replaced, got %q", data)
+	}
+}
+
+func TestSingleDownloaderClobber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		file      string
+		noClobber bool
+		expected  map[string]string
+	}{
+		{"Derived name is numbered", "", false, map[string]string{"report.txt": "old", "report.txt.1": "new"}},
+		{"Name from -O is overwritten", "report.txt", false, map[string]string{"report.txt": "new"}},
+		{"No clobber keeps the file", "", true, map[string]string{"report.txt": "old"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			app := newWgetState()
+			app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+			app.urlArgs.noClobber = test.noClobber
+			if err := os.WriteFile(filepath.Join(tempDir, "report.txt"), []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := app.singleDownloader(test.file, server.URL+"/report.txt", "", tempDir); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for name, content := range test.expected {
+				data, err := os.ReadFile(filepath.Join(tempDir, name))
+				if err != nil || string(data) != content {
+					t.Errorf("Expected %s to hold %q, got %q (%v)", name, content, data, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(tempDir, "report.txt.1")); err == nil && test.expected["report.txt.1"] == "" {
+				t.Errorf("Expected no numbered copy")
+			}
+		})
+	}
+}
+
+func TestSingleDownloaderClobberAfterFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		segments int
+	}{
+		{"Single stream", 0},
+		{"Segments", 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			app := newWgetState()
+			app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+			app.urlArgs.backups = 2
+			app.urlArgs.segments = test.segments
+			if err := os.WriteFile(filepath.Join(tempDir, "gone.txt"), []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := app.singleDownloader("", server.URL+"/gone.txt", "", tempDir); err == nil {
+				t.Fatalf("Expected the 404 to be reported, got nil")
+			}
+			data, err := os.ReadFile(filepath.Join(tempDir, "gone.txt"))
+			if err != nil || string(data) != "old" {
+				t.Errorf("Expected gone.txt to still hold %q, got %q (%v)", "old", data, err)
+			}
+			if _, err := os.Stat(filepath.Join(tempDir, "gone.txt.1")); !os.IsNotExist(err) {
+				t.Errorf("Expected no backup to be made, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestSingleDownloaderFailureLeavesNoFile(t *testing.T) {
+	missing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if missing {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("found"))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	app := newWgetState()
+	app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+
+	if err := app.singleDownloader("", server.URL+"/missing.bin", "", tempDir); err == nil {
+		t.Fatalf("Expected the 404 to be reported, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "missing.bin")); !os.IsNotExist(err) {
+		t.Errorf("Expected no missing.bin after the failure, but got %v", err)
+	}
+
+	// The name is free again for the next attempt
+	missing = false
+	if err := app.singleDownloader("", server.URL+"/missing.bin", "", tempDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, "missing.bin"))
+	if err != nil || string(data) != "found" {
+		t.Errorf("Expected missing.bin to hold %q, got %q (%v)", "found", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "missing.bin.1")); !os.IsNotExist(err) {
+		t.Errorf("Expected no numbered copy, but got %v", err)
+	}
+}
+
+func TestSingleDownloaderChecksum(t *testing.T) {
+	const digest = "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release.tar.gz":
+			w.Write([]byte("hello world\n"))
+		case "/release.tar.gz.sha256":
+			w.Write([]byte(digest + "  release.tar.gz\n"))
+		case "/broken.tar.gz":
+			w.Write([]byte("truncated"))
+		case "/broken.tar.gz.sha256":
+			w.Write([]byte(digest + "  broken.tar.gz\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	matching, err := wgetutils.ParseChecksum("sha256:" + digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		checksum    *wgetutils.Checksum
+		sidecar     bool
+		expectedErr bool
+	}{
+		{"Matching --checksum", "release.tar.gz", matching, false, false},
+		{"Mismatching --checksum", "broken.tar.gz", matching, false, true},
+		{"Matching sidecar", "release.tar.gz", nil, true, false},
+		{"Mismatching sidecar", "broken.tar.gz", nil, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			app := newWgetState()
+			app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+			app.urlArgs.checksum = test.checksum
+			app.urlArgs.checksumSidecar = test.sidecar
+
+			err := app.singleDownloader("", server.URL+"/"+test.file, "", tempDir)
+			_, statErr := os.Stat(filepath.Join(tempDir, test.file))
+			if test.expectedErr {
+				if err == nil {
+					t.Errorf("Expected a checksum error, got none")
+				}
+				if statErr == nil {
+					t.Errorf("Expected the corrupt file to be deleted")
+				}
+			} else if err != nil || statErr != nil {
+				t.Errorf("Expected a verified file, got %v / %v", err, statErr)
+			}
+		})
+	}
+}
+
+func TestSingleDownloaderTrustServerNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			http.Redirect(w, r, "/releases/tool-2.1.tar.gz", http.StatusFound)
+			return
+		}
+		w.Write([]byte("release"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		trust    bool
+		expected string
+	}{
+		{false, "latest"},
+		{true, "tool-2.1.tar.gz"},
+	}
+
+	for _, test := range tests {
+		tempDir := t.TempDir()
+		app := newWgetState()
+		app.tempConfigFile = filepath.Join(tempDir, "progress_config.txt")
+		app.urlArgs.trustServerNames = test.trust
+
+		if err := app.singleDownloader("", server.URL+"/latest", "", tempDir); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, test.expected)); err != nil {
+			t.Errorf("Expected file %s with trust-server-names=%v, got %v", test.expected, test.trust, err)
+		}
+	}
+}
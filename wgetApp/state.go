@@ -18,6 +18,7 @@ func InitWget() (*WgetApp, error) {
 		state = newWgetState()
 		err = state.parser()
 		err = state.taskManager(err)
+		err = state.saveCookies(err)
 	})
 
 	// Return any initialization errors (though none are expected here)
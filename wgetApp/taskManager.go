@@ -2,7 +2,8 @@ package wgetApp
 
 import (
 	"fmt"
-	"strings"
+
+	wgetutils "wget/wgetUtils"
 )
 
 func (app *WgetApp) taskManager(err error) error {
@@ -12,17 +13,16 @@ func (app *WgetApp) taskManager(err error) error {
 
 	// Mirror website handling
 	if app.urlArgs.mirroring {
-		err := app.mirror(app.urlArgs.url, app.urlArgs.rejectFlag, app.urlArgs.excludeFlag, app.urlArgs.convertLinksFlag)
+		err := app.mirror(app.urlArgs.url, app.urlArgs.convertLinksFlag)
 		if err != nil {
 			return err
 		}
 		return nil
 	}
 
-	// If no file name is provided, derive it from the url
-	if app.urlArgs.file == "" && app.urlArgs.url != "" {
-		urlParts := strings.Split(app.urlArgs.url, "/")
-		app.urlArgs.file = urlParts[len(urlParts)-1]
+	// Single page snapshot handling
+	if app.urlArgs.pageRequisites {
+		return app.pageRequisites(app.urlArgs.url, app.urlArgs.convertLinksFlag)
 	}
 
 	// Handle the work-in-background flag
@@ -43,6 +43,15 @@ func (app *WgetApp) taskManager(err error) error {
 		return nil
 	}
 
+	// Handle downloads described by a local Metalink file
+	if app.urlArgs.metalinkFile != "" {
+		metalink, err := wgetutils.LoadMetalink(app.urlArgs.metalinkFile)
+		if err != nil {
+			return err
+		}
+		return app.downloadMetalink(metalink, app.urlArgs.rateLimit, app.urlArgs.path)
+	}
+
 	// Ensure url is provided
 	if app.urlArgs.url == "" {
 		return fmt.Errorf("error: url not provided")
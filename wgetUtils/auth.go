@@ -0,0 +1,243 @@
+package wgetutils
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// authChallenge is one scheme offered by a server in a WWW-Authenticate header.
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseChallenges parses WWW-Authenticate header values. A single value may hold several
+// challenges, e.g. `Digest realm="x", nonce="y", Basic realm="x"`.
+func parseChallenges(values []string) []authChallenge {
+	var challenges []authChallenge
+	for _, value := range values {
+		var current *authChallenge
+		s := strings.TrimSpace(value)
+		for s != "" {
+			token := readToken(&s)
+			if token == "" {
+				// Skip anything we can't make sense of
+				s = strings.TrimLeft(s[1:], " \t,")
+				continue
+			}
+			if strings.HasPrefix(s, "=") {
+				s = s[1:]
+				if current != nil {
+					current.params[strings.ToLower(token)] = readValue(&s)
+				} else {
+					readValue(&s)
+				}
+			} else {
+				challenges = append(challenges, authChallenge{scheme: strings.ToLower(token), params: map[string]string{}})
+				current = &challenges[len(challenges)-1]
+			}
+			s = strings.TrimLeft(s, " \t,")
+		}
+	}
+	return challenges
+}
+
+// readToken consumes a header token from the front of s.
+func readToken(s *string) string {
+	i := strings.IndexAny(*s, " \t,=\"")
+	if i == -1 {
+		i = len(*s)
+	}
+	token := (*s)[:i]
+	*s = strings.TrimLeft((*s)[i:], " \t")
+	return token
+}
+
+// readValue consumes a token or a quoted string from the front of s.
+func readValue(s *string) string {
+	*s = strings.TrimLeft(*s, " \t")
+	if !strings.HasPrefix(*s, "\"") {
+		i := strings.IndexAny(*s, " \t,")
+		if i == -1 {
+			i = len(*s)
+		}
+		value := (*s)[:i]
+		*s = (*s)[i:]
+		return value
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(*s); i++ {
+		switch c := (*s)[i]; c {
+		case '\\':
+			if i+1 < len(*s) {
+				i++
+				sb.WriteByte((*s)[i])
+			}
+		case '"':
+			*s = (*s)[i+1:]
+			return sb.String()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	*s = ""
+	return sb.String()
+}
+
+// credentialsFor returns the credentials to use for host. Explicit --user/--password
+// always win; otherwise the matching .netrc entry is used.
+func credentialsFor(host string) (string, string) {
+	user, password := clientOptions.User, clientOptions.Password
+	entry, found := clientOptions.Netrc.Lookup(host)
+	switch {
+	case user == "" && found:
+		return entry.Login, entry.Password
+	case user != "" && password == "" && found && entry.Login == user:
+		return user, entry.Password
+	}
+	return user, password
+}
+
+// authorizationFor answers the strongest supported challenge in a 401 response.
+// It returns an empty string when the server offers nothing we support.
+func authorizationFor(resp *http.Response, req *http.Request, user, password string) string {
+	var basic, digest *authChallenge
+	challenges := parseChallenges(resp.Header.Values("WWW-Authenticate"))
+	for i := range challenges {
+		switch challenges[i].scheme {
+		case "digest":
+			if digest == nil && digestHash(challenges[i].params["algorithm"]) != nil {
+				digest = &challenges[i]
+			}
+		case "basic":
+			basic = &challenges[i]
+		}
+	}
+
+	switch {
+	case digest != nil:
+		return digestAuthorization(digest.params, req.Method, req.URL.RequestURI(), user, password)
+	case basic != nil:
+		return basicAuthorization(user, password)
+	}
+	return ""
+}
+
+// basicAuthorization builds a Basic Authorization header value (RFC 7617).
+func basicAuthorization(user, password string) string {
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth(user, password)
+	return req.Header.Get("Authorization")
+}
+
+// digestHash returns the hash constructor for a Digest algorithm, or nil if unsupported.
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+// digestAuthorization builds a Digest Authorization header value (RFC 7616) for the
+// challenge parameters, using qop=auth when the server offers it.
+func digestAuthorization(params map[string]string, method, uri, user, password string) string {
+	algorithm := params["algorithm"]
+	newHash := digestHash(algorithm)
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	realm, nonce := params["realm"], params["nonce"]
+	cnonce := newCnonce()
+	nc := "00000001"
+
+	ha1 := h(user + ":" + realm + ":" + password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	qop := ""
+	for _, option := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(option) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	var response string
+	if qop != "" {
+		response = h(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf("username=%q", user),
+		fmt.Sprintf("realm=%q", realm),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("response=%q", response),
+	}
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+	if qop != "" {
+		fields = append(fields, "qop="+qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if opaque, ok := params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+// newCnonce returns a random client nonce for Digest authentication.
+var newCnonce = func() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ReadPassword prompts on the terminal and reads a password without echoing it.
+func ReadPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("error opening terminal to read password:\n%v", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+
+	// Turn off echo for the duration of the read, and always turn it back on
+	echoOff := exec.Command("stty", "-echo")
+	echoOff.Stdin = tty
+	if err := echoOff.Run(); err != nil {
+		return "", fmt.Errorf("error disabling terminal echo:\n%v", err)
+	}
+	defer func() {
+		echoOn := exec.Command("stty", "echo")
+		echoOn.Stdin = tty
+		echoOn.Run()
+		fmt.Fprintln(tty)
+	}()
+
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("error reading password:\n%v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
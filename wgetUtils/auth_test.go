@@ -0,0 +1,99 @@
+package wgetutils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseChallenges(t *testing.T) {
+	challenges := parseChallenges([]string{
+		`Digest realm="a \"quoted\" realm", qop="auth,auth-int", nonce=abc, Basic realm="fallback"`,
+	})
+
+	if len(challenges) != 2 {
+		t.Fatalf("Expected 2 challenges, but got %d", len(challenges))
+	}
+	if challenges[0].scheme != "digest" || challenges[0].params["realm"] != `a "quoted" realm` ||
+		challenges[0].params["qop"] != "auth,auth-int" || challenges[0].params["nonce"] != "abc" {
+		t.Errorf("Unexpected digest challenge: %+v", challenges[0])
+	}
+	if challenges[1].scheme != "basic" || challenges[1].params["realm"] != "fallback" {
+		t.Errorf("Unexpected basic challenge: %+v", challenges[1])
+	}
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	// Example from RFC 7616 section 3.9.1
+	defer func(original func() string) { newCnonce = original }(newCnonce)
+	newCnonce = func() string { return "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ" }
+
+	tests := []struct {
+		algorithm string
+		expected  string
+	}{
+		{"MD5", `response="8ca523f5e9506fed4657c9700eebdbec"`},
+		{"SHA-256", `response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"`},
+	}
+
+	for _, test := range tests {
+		params := map[string]string{
+			"realm":     "http-auth@example.org",
+			"qop":       "auth, auth-int",
+			"algorithm": test.algorithm,
+			"nonce":     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+			"opaque":    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+		}
+		header := digestAuthorization(params, "GET", "/dir/index.html", "Mufasa", "Circle of Life")
+		if !strings.Contains(header, test.expected) {
+			t.Errorf("Expected %s for %s, but got %s", test.expected, test.algorithm, header)
+		}
+	}
+}
+
+func TestHttpRequestAuthentication(t *testing.T) {
+	var leaked string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("Authorization")
+	}))
+	defer other.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/elsewhere" {
+			http.Redirect(w, r, other.URL, http.StatusFound)
+			return
+		}
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "alice" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="artifacts"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("welcome"))
+	}))
+	defer ts.Close()
+
+	defer SetClientOptions(ClientOptions{})
+	SetClientOptions(ClientOptions{User: "alice", Password: "secret"})
+
+	resp, err := HttpRequest(ts.URL + "/file")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after answering the challenge, but got %d", resp.StatusCode)
+	}
+
+	// Preemptive credentials must be dropped when redirected to another host
+	SetClientOptions(ClientOptions{User: "alice", Password: "secret", AuthNoChallenge: true})
+	resp, err = HttpRequest(ts.URL + "/elsewhere")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	resp.Body.Close()
+	if leaked != "" {
+		t.Errorf("Expected no credentials on the redirected host, but got %q", leaked)
+	}
+}
@@ -0,0 +1,124 @@
+package wgetutils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// checksumAlgorithms lists the digests accepted by --checksum.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Checksum is the digest a download is expected to have.
+type Checksum struct {
+	Algorithm string
+	Sum       []byte
+}
+
+// ParseChecksum parses a --checksum value of the form "sha256:<hex>".
+func ParseChecksum(value string) (*Checksum, error) {
+	algorithm, digest, found := strings.Cut(value, ":")
+	algorithm = strings.ToLower(algorithm)
+	newHash, ok := checksumAlgorithms[algorithm]
+	if !found || !ok {
+		return nil, fmt.Errorf("invalid --checksum value %q.\nUsage: --checksum=sha256:<hex> (md5, sha1, sha256 or sha512)", value)
+	}
+	sum, err := hex.DecodeString(strings.TrimSpace(digest))
+	if err != nil || len(sum) != newHash().Size() {
+		return nil, fmt.Errorf("invalid %s digest %q", algorithm, digest)
+	}
+	return &Checksum{Algorithm: algorithm, Sum: sum}, nil
+}
+
+// NewHash returns a hash to feed the downloaded bytes into.
+func (c *Checksum) NewHash() hash.Hash {
+	return checksumAlgorithms[c.Algorithm]()
+}
+
+// Verify compares the digest of the downloaded bytes with the expected one.
+func (c *Checksum) Verify(sum []byte) error {
+	if !bytes.Equal(sum, c.Sum) {
+		return fmt.Errorf("checksum mismatch: expected %s:%x but got %s:%x", c.Algorithm, c.Sum, c.Algorithm, sum)
+	}
+	return nil
+}
+
+// HashFile feeds the contents of path into h, e.g. the part of a file that was already
+// on disk before a download resumed.
+func HashFile(h hash.Hash, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error reading file for checksum:\n%v", err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(h, file); err != nil {
+		return fmt.Errorf("error reading file for checksum:\n%v", err)
+	}
+	return nil
+}
+
+// FetchSidecarChecksum downloads "<url>.sha256" and returns the digest it lists for the
+// file at fileURL.
+func FetchSidecarChecksum(fileURL string) (*Checksum, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	name := path.Base(u.Path)
+	u.Path += ".sha256"
+
+	resp, err := HttpRequest(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching checksum file:\n%v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching checksum file: status %s\nurl: [%s]", resp.Status, u)
+	}
+
+	// A checksum file is a few lines at most; refuse anything suspiciously large
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading checksum file:\n%v", err)
+	}
+	return ParseSidecar(string(data), name)
+}
+
+// ParseSidecar reads a SHA-256 digest from the contents of a sidecar file: either a bare
+// digest, or sha256sum output ("<hex>  name") from which the line for name is used.
+func ParseSidecar(data, name string) (*Checksum, error) {
+	var digests []string
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		// sha256sum marks binary mode with a "*" before the name
+		if len(fields) > 1 && strings.TrimPrefix(fields[1], "*") == name {
+			return ParseChecksum("sha256:" + fields[0])
+		}
+		digests = append(digests, fields[0])
+	}
+
+	// A file with a single digest is taken to be about this download, whatever name it lists
+	if len(digests) != 1 {
+		return nil, fmt.Errorf("error reading checksum file: no digest found for %s", name)
+	}
+	return ParseChecksum("sha256:" + digests[0])
+}
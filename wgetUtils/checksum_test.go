@@ -0,0 +1,83 @@
+package wgetutils
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// sha256 of "hello world\n", as printed by sha256sum
+const helloSHA256 = "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		value       string
+		algorithm   string
+		expectedErr bool
+	}{
+		{"sha256:" + helloSHA256, "sha256", false},
+		{"SHA256:" + helloSHA256, "sha256", false},
+		{"md5:6f5902ac237024bdd0c176cb93063dc4", "md5", false},
+		{"sha1:22596363b3de40b06f981fb85d82312e8c0ed511", "sha1", false},
+		{"sha256:abc", "", true},
+		{"sha256:" + helloSHA256[:62] + "zz", "", true},
+		{"crc32:12345678", "", true},
+		{helloSHA256, "", true},
+	}
+
+	for _, test := range tests {
+		checksum, err := ParseChecksum(test.value)
+		if err != nil && !test.expectedErr {
+			t.Errorf("Unexpected error for %q: %v", test.value, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %q, but got none", test.value)
+		} else if err == nil && checksum.Algorithm != test.algorithm {
+			t.Errorf("Expected %s for %q, but got %s", test.algorithm, test.value, checksum.Algorithm)
+		}
+	}
+}
+
+func TestChecksumVerify(t *testing.T) {
+	checksum, err := ParseChecksum("sha256:" + helloSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := checksum.NewHash()
+	digest.Write([]byte("hello "))
+	digest.Write([]byte("world\n"))
+	if err := checksum.Verify(digest.Sum(nil)); err != nil {
+		t.Errorf("Expected streamed digest to match, but got %v", err)
+	}
+
+	digest.Reset()
+	digest.Write([]byte("hello there\n"))
+	if err := checksum.Verify(digest.Sum(nil)); err == nil {
+		t.Errorf("Expected a mismatch for different content")
+	}
+}
+
+func TestParseSidecar(t *testing.T) {
+	other := "0000000000000000000000000000000000000000000000000000000000000000"
+	tests := []struct {
+		data        string
+		expected    string
+		expectedErr bool
+	}{
+		{helloSHA256 + "\n", helloSHA256, false},
+		{helloSHA256 + "  release.tar.gz\n", helloSHA256, false},
+		{other + "  notes.txt\n" + helloSHA256 + " *release.tar.gz\n", helloSHA256, false},
+		{other + "  notes.txt\n" + helloSHA256 + "  other.tar.gz\n", "", true},
+		{"# no digests here\n", "", true},
+	}
+
+	for _, test := range tests {
+		checksum, err := ParseSidecar(test.data, "release.tar.gz")
+		if err != nil && !test.expectedErr {
+			t.Errorf("Unexpected error for %q: %v", test.data, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %q, but got none", test.data)
+		} else if err == nil && hex.EncodeToString(checksum.Sum) != test.expected {
+			t.Errorf("Expected %s for %q, but got %x", test.expected, test.data, checksum.Sum)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package wgetutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ClientOptions holds the connection settings shared by every request the tool sends.
+type ClientOptions struct {
+	ConnectTimeout  time.Duration  // limit for establishing the TCP/TLS connection
+	ReadTimeout     time.Duration  // limit for the server staying silent, reset on every read
+	Timeout         time.Duration  // limit for a whole request, including reading the body
+	UserAgent       string         // replaces the default browser User-Agent when set
+	Headers         http.Header    // extra headers; they replace defaults of the same name
+	Jar             http.CookieJar // cookie store shared by every request in the run
+	User            string         // credentials for Basic and Digest authentication
+	Password        string
+	Netrc           *Netrc // per-host credentials used when no --user is given
+	AuthNoChallenge bool   // send Basic credentials before the server asks for them
+	Proxy           string // proxy URL used instead of the http_proxy/https_proxy variables
+	NoProxy         bool   // connect directly, ignoring any configured proxy
+	ProxyUser       string // credentials for the proxy itself
+	ProxyPassword   string
+
+	CACertificate      string // PEM bundle trusted in addition to the system roots
+	CADirectory        string // directory of PEM files trusted in addition to the system roots
+	Certificate        string // client certificate for mutual TLS
+	PrivateKey         string // key for Certificate, when not stored in the same file
+	NoCheckCertificate bool   // skip server certificate verification
+	SecureProtocol     string // minimum TLS version, see ParseSecureProtocol
+
+	MaxRedirect            int  // redirects to follow; 0 means the default of 20, negative means none
+	AllowInsecureRedirects bool // follow redirects from https to plain http
+
+	Compression string // "auto" (the default when empty), "gzip" or "none", see ParseCompression
+}
+
+// defaultMaxRedirect matches the redirect limit of GNU wget.
+const defaultMaxRedirect = 20
+
+// defaultUserAgent mimics a Chrome browser, which some servers expect.
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.85 Safari/537.36"
+
+var (
+	clientOptions ClientOptions
+	httpClient    = &http.Client{}
+)
+
+// SetClientOptions rebuilds the shared HTTP client used by HttpRequest from the given options.
+func SetClientOptions(opts ClientOptions) error {
+	dialer := &net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Compressed bodies are negotiated and decoded by decodeBody, the same way for every request
+	transport.DisableCompression = true
+	if opts.ConnectTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	}
+	if opts.ReadTimeout > 0 {
+		transport.ResponseHeaderTimeout = opts.ReadTimeout
+	}
+	proxy, err := proxyFunc(opts)
+	if err != nil {
+		return err
+	}
+	transport.Proxy = proxy
+	transport.TLSClientConfig, err = tlsConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	clientOptions = opts
+	httpClient = &http.Client{
+		Transport:     transport,
+		Timeout:       opts.Timeout,
+		Jar:           opts.Jar,
+		CheckRedirect: checkRedirect,
+	}
+	return nil
+}
+
+// checkRedirect reports every redirect hop the way wget does, enforces --max-redirect and
+// refuses to leave https for plain http unless --allow-insecure-redirects is given.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	limit := clientOptions.MaxRedirect
+	if limit == 0 {
+		limit = defaultMaxRedirect
+	}
+	if len(via) > limit {
+		return fmt.Errorf("%d redirections exceeded", max(limit, 0))
+	}
+
+	previous := via[len(via)-1]
+	if previous.URL.Scheme == "https" && req.URL.Scheme == "http" && !clientOptions.AllowInsecureRedirects {
+		return fmt.Errorf("refusing redirect from https to http: %s\nuse --allow-insecure-redirects to follow it", req.URL)
+	}
+	fmt.Printf("status %s\nLocation: %s [following]\n", req.Response.Status, req.URL)
+
+	// Credentials belong to the host they were given for
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("Authorization")
+	}
+	return keepRedirectMethod(req, via)
+}
+
+// keepRedirectMethod undoes net/http turning every method into GET on a 301 or 302.
+// Browsers only do that for POST, and keep methods such as PUT along with their body;
+// a 303 always becomes a GET, and a 307 or 308 always keeps the method and body.
+func keepRedirectMethod(req *http.Request, via []*http.Request) error {
+	previous, original := via[len(via)-1], via[0]
+	switch req.Response.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound:
+		if previous.Method == "GET" || previous.Method == "HEAD" || previous.Method == "POST" {
+			return nil
+		}
+		req.Method = previous.Method
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil
+	}
+
+	// net/http drops the body for good once a hop rewrote the method, so put it back
+	if req.Body != nil || original.GetBody == nil || req.Method == "GET" || req.Method == "HEAD" {
+		return nil
+	}
+	body, err := original.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body, req.GetBody, req.ContentLength = body, original.GetBody, original.ContentLength
+	if contentType := original.Header.Get("Content-Type"); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return nil
+}
+
+// ParseHeader splits a --header value of the form "Name: value". An empty value is
+// allowed and removes the header from requests.
+func ParseHeader(header string) (string, string, error) {
+	name, value, found := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q.\nUsage: --header=\"Name: value\"", header)
+	}
+	return http.CanonicalHeaderKey(name), strings.TrimSpace(value), nil
+}
+
+// applyHeaders sets the browser-like default headers on req, then layers the
+// configured User-Agent and custom headers on top of them.
+func applyHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Connection", "keep-alive")
+	if encoding := acceptEncoding(); encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+
+	if clientOptions.UserAgent != "" {
+		req.Header.Set("User-Agent", clientOptions.UserAgent)
+	}
+	for name, values := range clientOptions.Headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			if value != "" {
+				req.Header.Add(name, value)
+			}
+		}
+	}
+}
+
+// ParseSeconds parses a timeout given in (possibly fractional) seconds, e.g. "30" or "0.5".
+func ParseSeconds(flag, value string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid %s value.\nUsage: %s=30", flag, flag)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// describeTimeout turns the various timeout errors of net/http into a message that says
+// which limit was hit. Other errors are returned unchanged.
+func describeTimeout(err error) error {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return err
+	}
+
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial", strings.Contains(err.Error(), "TLS handshake timeout"):
+		return fmt.Errorf("connect timeout: no connection within %s", clientOptions.ConnectTimeout)
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return fmt.Errorf("read timeout: no response within %s", clientOptions.ReadTimeout)
+	case strings.Contains(err.Error(), "Client.Timeout"):
+		return fmt.Errorf("timeout: request did not complete within %s", clientOptions.Timeout)
+	}
+	return err
+}
+
+// timeoutBody wraps a response body so that a server that goes silent for longer than
+// the read timeout fails the read instead of hanging, and so that timeout errors raised
+// while reading the body carry a clear message.
+type timeoutBody struct {
+	body     io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+// newTimeoutBody starts the idle timer for body. A zero timeout disables the idle timer.
+func newTimeoutBody(body io.ReadCloser, timeout time.Duration) *timeoutBody {
+	b := &timeoutBody{body: body, timeout: timeout}
+	if timeout > 0 {
+		// Closing the body is the only way to interrupt a blocked Read
+		b.timer = time.AfterFunc(timeout, func() {
+			b.timedOut.Store(true)
+			body.Close()
+		})
+	}
+	return b
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.timedOut.Load() {
+		return n, fmt.Errorf("read timeout: no data received for %s", b.timeout)
+	}
+	if b.timer != nil {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF {
+		err = describeTimeout(err)
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b.body.Close()
+}
@@ -0,0 +1,219 @@
+package wgetutils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSeconds(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    time.Duration
+		expectedErr bool
+	}{
+		{"30", 30 * time.Second, false},
+		{"0.5", 500 * time.Millisecond, false},
+		{"-1", 0, true},
+		{"30s", 0, true},
+	}
+
+	for _, test := range tests {
+		timeout, err := ParseSeconds("--timeout", test.input)
+		if err != nil && !test.expectedErr {
+			t.Errorf("Expected no error for %s, but got %v", test.input, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %s, but got none", test.input)
+		} else if timeout != test.expected {
+			t.Errorf("Expected %s for %s, but got %s", test.expected, test.input, timeout)
+		}
+	}
+}
+
+func TestClientTimeouts(t *testing.T) {
+	// The server answers quickly, then stalls in the middle of the body
+	stalledBody := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer stalledBody.Close()
+
+	// The server takes too long to send any response at all
+	stalledHeaders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer stalledHeaders.Close()
+
+	defer SetClientOptions(ClientOptions{})
+
+	tests := []struct {
+		name     string
+		opts     ClientOptions
+		url      string
+		expected string
+	}{
+		{"Idle body", ClientOptions{ReadTimeout: 100 * time.Millisecond}, stalledBody.URL, "read timeout: no data received"},
+		{"Idle headers", ClientOptions{ReadTimeout: 100 * time.Millisecond}, stalledHeaders.URL, "read timeout: no response"},
+		{"Overall", ClientOptions{Timeout: 100 * time.Millisecond}, stalledHeaders.URL, "timeout: request did not complete"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := SetClientOptions(test.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := HttpRequest(test.url)
+			if err == nil {
+				_, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error containing %q, but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		header      string
+		name        string
+		value       string
+		expectedErr bool
+	}{
+		{"X-Token: abc", "X-Token", "abc", false},
+		{"accept:application/json", "Accept", "application/json", false},
+		{"Accept-Language:", "Accept-Language", "", false},
+		{"no colon", "", "", true},
+		{": value", "", "", true},
+	}
+
+	for _, test := range tests {
+		name, value, err := ParseHeader(test.header)
+		if err != nil && !test.expectedErr {
+			t.Errorf("Expected no error for %q, but got %v", test.header, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %q, but got none", test.header)
+		} else if name != test.name || value != test.value {
+			t.Errorf("Expected %q/%q for %q, but got %q/%q", test.name, test.value, test.header, name, value)
+		}
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	defer SetClientOptions(ClientOptions{})
+	err := SetClientOptions(ClientOptions{
+		UserAgent: "internal-fetcher/1.0",
+		Headers: http.Header{
+			"Accept":          {"application/json"},
+			"Accept-Language": {""},
+			"X-Token":         {"abc", "def"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := HttpRequest(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	resp.Body.Close()
+
+	if got := received.Get("User-Agent"); got != "internal-fetcher/1.0" {
+		t.Errorf("Expected custom User-Agent, but got %q", got)
+	}
+	if got := received.Get("Accept"); got != "application/json" {
+		t.Errorf("Expected Accept to be overridden, but got %q", got)
+	}
+	if got := received.Get("Accept-Language"); got != "" {
+		t.Errorf("Expected Accept-Language to be removed, but got %q", got)
+	}
+	if got := received.Values("X-Token"); len(got) != 2 {
+		t.Errorf("Expected both X-Token values, but got %q", got)
+	}
+}
+
+func TestMaxRedirect(t *testing.T) {
+	// /hops/N redirects N more times before answering
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hops int
+		fmt.Sscanf(r.URL.Path, "/hops/%d", &hops)
+		if hops > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/hops/%d", hops-1), http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		maxRedirect int
+		hops        int
+		expectedErr bool
+	}{
+		{0, 20, false},
+		{0, 21, true},
+		{3, 3, false},
+		{3, 4, true},
+		{-1, 0, false},
+		{-1, 1, true},
+	}
+
+	defer SetClientOptions(ClientOptions{})
+	SetRetryPolicy(RetryPolicy{Tries: 1})
+	defer SetRetryPolicy(DefaultRetryPolicy())
+
+	for _, test := range tests {
+		if err := SetClientOptions(ClientOptions{MaxRedirect: test.maxRedirect}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := HttpRequest(fmt.Sprintf("%s/hops/%d", ts.URL, test.hops))
+		if err == nil {
+			resp.Body.Close()
+		}
+		if err != nil && !test.expectedErr {
+			t.Errorf("Expected %d hops to be followed with limit %d, but got %v", test.hops, test.maxRedirect, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected %d hops to exceed limit %d, but got no error", test.hops, test.maxRedirect)
+		}
+	}
+}
+
+func TestInsecureRedirect(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/file", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	defer SetClientOptions(ClientOptions{})
+	SetRetryPolicy(RetryPolicy{Tries: 1})
+	defer SetRetryPolicy(DefaultRetryPolicy())
+
+	for _, allow := range []bool{false, true} {
+		if err := SetClientOptions(ClientOptions{NoCheckCertificate: true, AllowInsecureRedirects: allow}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := HttpRequest(secure.URL + "/file")
+		if err == nil {
+			resp.Body.Close()
+		}
+		if allow && err != nil {
+			t.Errorf("Expected the redirect to be followed when allowed, but got %v", err)
+		} else if !allow && (err == nil || !strings.Contains(err.Error(), "https to http")) {
+			t.Errorf("Expected the https to http redirect to be refused, but got %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package wgetutils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ClobberPolicy decides what happens when a download is about to be saved under a name
+// that is already taken. Without any option set, the existing file is overwritten.
+type ClobberPolicy struct {
+	NoClobber bool // -nc: keep the existing file and skip the download
+	Backups   int  // --backups=N: rotate the existing file to name.1 ... name.N first
+	Number    bool // save under the first free name.1, name.2, ... like wget does by default
+
+	// Taken reports names claimed by downloads that have not written them yet, which are
+	// treated as taken like files on disk. It may be nil.
+	Taken func(path string) bool
+}
+
+// ParseBackups parses the --backups value, the number of old copies to keep.
+func ParseBackups(value string) (int, error) {
+	backups, err := strconv.Atoi(value)
+	if err != nil || backups < 0 {
+		return 0, fmt.Errorf("invalid --backups value.\nUsage: --backups=3")
+	}
+	return backups, nil
+}
+
+// PrepareOutput applies the policy to path before a new download is written to it. It
+// returns the path to write to, or "" when the existing file has to be kept.
+func (p ClobberPolicy) PrepareOutput(path string) (string, error) {
+	_, err := os.Lstat(path)
+	onDisk := err == nil
+	if !onDisk && !p.taken(path) {
+		return path, nil
+	}
+
+	switch {
+	case p.NoClobber:
+		return "", nil
+	case p.Backups > 0:
+		// A name that is only claimed has nothing to back up yet
+		if !onDisk {
+			return path, nil
+		}
+		return path, rotateBackups(path, p.Backups)
+	case p.Number:
+		for i := 1; ; i++ {
+			candidate := path + "." + strconv.Itoa(i)
+			if _, err := os.Lstat(candidate); os.IsNotExist(err) && !p.taken(candidate) {
+				return candidate, nil
+			}
+		}
+	}
+	return path, nil
+}
+
+func (p ClobberPolicy) taken(path string) bool {
+	return p.Taken != nil && p.Taken(path)
+}
+
+// rotateBackups shifts path.1 ... path.(n-1) up by one, dropping path.n, and moves path
+// itself to path.1.
+func rotateBackups(path string, n int) error {
+	backup := func(i int) string { return path + "." + strconv.Itoa(i) }
+
+	if err := os.Remove(backup(n)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error rotating backups:\n%v", err)
+	}
+	for i := n - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rotating backups:\n%v", err)
+		}
+	}
+	if err := os.Rename(path, backup(1)); err != nil {
+		return fmt.Errorf("error rotating backups:\n%v", err)
+	}
+	return nil
+}
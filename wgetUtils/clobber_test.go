@@ -0,0 +1,93 @@
+package wgetutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrepareOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ClobberPolicy
+		existing []string
+		claimed  []string
+		expected string
+	}{
+		{"Free name", ClobberPolicy{Number: true}, nil, nil, "file.txt"},
+		{"Overwrite", ClobberPolicy{}, []string{"file.txt"}, nil, "file.txt"},
+		{"No clobber", ClobberPolicy{NoClobber: true}, []string{"file.txt"}, nil, ""},
+		{"Numbered", ClobberPolicy{Number: true}, []string{"file.txt", "file.txt.1"}, nil, "file.txt.2"},
+		{"Claimed names are numbered", ClobberPolicy{Number: true}, []string{"file.txt.1"}, []string{"file.txt", "file.txt.2"}, "file.txt.3"},
+		{"Claimed name with no clobber", ClobberPolicy{NoClobber: true}, nil, []string{"file.txt"}, ""},
+		{"Claimed name is not backed up", ClobberPolicy{Backups: 2}, nil, []string{"file.txt"}, "file.txt"},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		for _, name := range test.existing {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		claimed := map[string]bool{}
+		for _, name := range test.claimed {
+			claimed[filepath.Join(dir, name)] = true
+		}
+		test.policy.Taken = func(path string) bool { return claimed[path] }
+
+		result, err := test.policy.PrepareOutput(filepath.Join(dir, "file.txt"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		expected := test.expected
+		if expected != "" {
+			expected = filepath.Join(dir, expected)
+		}
+		if result != expected {
+			t.Errorf("%s: expected %q, but got %q", test.name, expected, result)
+		}
+	}
+}
+
+func TestPrepareOutputBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	policy := ClobberPolicy{Backups: 2}
+
+	// Each download keeps the current file and the two before it
+	for _, content := range []string{"first", "second", "third", "fourth"} {
+		target, err := policy.PrepareOutput(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != path {
+			t.Fatalf("Expected to write to %s, but got %s", path, target)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{"file.txt": "fourth", "file.txt.1": "third", "file.txt.2": "second"}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to hold %q, but got %q (%v)", name, content, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file.txt.3")); err == nil {
+		t.Errorf("Expected no more than 2 backups")
+	}
+}
+
+func TestParseBackups(t *testing.T) {
+	if backups, err := ParseBackups("3"); err != nil || backups != 3 {
+		t.Errorf("Expected 3, but got %d (%v)", backups, err)
+	}
+	for _, value := range []string{"-1", "many", ""} {
+		if _, err := ParseBackups(value); err == nil {
+			t.Errorf("Expected error for %q, but got none", value)
+		}
+	}
+}
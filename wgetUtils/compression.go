@@ -0,0 +1,142 @@
+package wgetutils
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ParseCompression validates a --compression value.
+func ParseCompression(value string) error {
+	switch value {
+	case "auto", "gzip", "none":
+		return nil
+	}
+	return fmt.Errorf("invalid --compression value %q.\nUsage: --compression=auto|gzip|none", value)
+}
+
+// acceptEncoding returns the Accept-Encoding header for the configured compression mode,
+// or "" to send none.
+func acceptEncoding() string {
+	switch clientOptions.Compression {
+	case "none":
+		return ""
+	case "gzip":
+		return "gzip"
+	}
+	return "gzip, deflate, br"
+}
+
+// DecodedBody is a response body that is decompressed while it is read. It counts the
+// compressed bytes as they arrive, so progress can still be reported against the
+// Content-Length the server sent for the encoded body.
+type DecodedBody struct {
+	Encoding      string // the Content-Encoding that was removed, e.g. "gzip"
+	EncodedLength int64  // the compressed Content-Length, or -1 if unknown
+
+	raw     io.ReadCloser
+	read    atomic.Int64
+	decoder io.Reader
+	err     error
+}
+
+// EncodedRead returns the number of compressed bytes read from the network so far.
+func (b *DecodedBody) EncodedRead() int64 {
+	return b.read.Load()
+}
+
+func (b *DecodedBody) Read(p []byte) (int, error) {
+	// Decoders read a header as soon as they are created, so wait for the first Read
+	if b.decoder == nil && b.err == nil {
+		b.decoder, b.err = newDecoder(b.Encoding, countingReader{b.raw, &b.read})
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.decoder.Read(p)
+}
+
+func (b *DecodedBody) Close() error {
+	return b.raw.Close()
+}
+
+// countingReader adds the number of bytes read through it to n.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// newDecoder returns a decompressing reader for a Content-Encoding.
+func newDecoder(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// "deflate" should be zlib-wrapped, but some servers send a raw deflate stream
+		buffered := bufio.NewReader(r)
+		header, err := buffered.Peek(2)
+		if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	case "br":
+		return brotli.NewReader(r), nil
+	}
+	return nil, fmt.Errorf("unsupported Content-Encoding %q", encoding)
+}
+
+// decodeBody replaces a compressed response body with one that decompresses on the fly.
+// The headers then describe the decoded body, whose length is not known in advance.
+func decodeBody(resp *http.Response) {
+	if clientOptions.Compression == "none" || resp.Request.Method == "HEAD" ||
+		resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "gzip", "x-gzip", "deflate", "br":
+	default:
+		return
+	}
+
+	if isGzipArchive(resp) {
+		return
+	}
+
+	resp.Body = &DecodedBody{Encoding: encoding, EncodedLength: resp.ContentLength, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// isGzipArchive reports whether a response is a .gz file in its own right, which servers
+// often label with Content-Encoding: gzip. Like wget, such files are saved as sent.
+func isGzipArchive(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/gzip", "application/x-gzip":
+		return true
+	}
+	switch strings.ToLower(path.Ext(resp.Request.URL.Path)) {
+	case ".gz", ".tgz":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package wgetutils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestHttpRequestDecodesBody(t *testing.T) {
+	page := strings.Repeat("<p>compressible page</p>\n", 200)
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":        func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate":     func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw-deflate": func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw },
+		"br":          func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+	}
+
+	var acceptEncoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		encoding := strings.TrimPrefix(r.URL.Path, "/")
+		var compressed bytes.Buffer
+		encoder := encoders[encoding](&compressed)
+		encoder.Write([]byte(page))
+		encoder.Close()
+
+		w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+		w.Header().Set("Content-Length", fmt.Sprint(compressed.Len()))
+		w.Write(compressed.Bytes())
+	}))
+	defer ts.Close()
+
+	defer SetClientOptions(ClientOptions{})
+	if err := SetClientOptions(ClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for encoding := range encoders {
+		resp, err := HttpRequest(ts.URL + "/" + encoding)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if err != nil || string(body) != page {
+			t.Errorf("%s: expected the decoded page, but got %d bytes (%v)", encoding, len(body), err)
+		}
+		if acceptEncoding != "gzip, deflate, br" {
+			t.Errorf("Expected Accept-Encoding \"gzip, deflate, br\", but got %q", acceptEncoding)
+		}
+		decoded, ok := resp.Body.(*DecodedBody)
+		if !ok {
+			t.Fatalf("%s: expected a decoded body", encoding)
+		}
+		if resp.ContentLength != -1 || decoded.EncodedLength <= 0 || decoded.EncodedRead() != decoded.EncodedLength {
+			t.Errorf("%s: expected unknown decoded length and %d compressed bytes read, but got %d and %d",
+				encoding, decoded.EncodedLength, resp.ContentLength, decoded.EncodedRead())
+		}
+	}
+}
+
+func TestHttpRequestKeepsGzipArchives(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	gz.Write([]byte("tarball contents"))
+	gz.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType := r.URL.Query().Get("type"); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(archive.Bytes())
+	}))
+	defer ts.Close()
+
+	defer SetClientOptions(ClientOptions{})
+	if err := SetClientOptions(ClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		decoded bool
+	}{
+		{"/release.tar.gz", false},
+		{"/release.TGZ", false},
+		{"/download?type=application/x-gzip", false},
+		{"/download?type=application/gzip%3B%20charset=binary", false},
+		{"/page.html?type=text/html", true},
+	}
+	for _, test := range tests {
+		resp, err := HttpRequest(ts.URL + test.path)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		_, decoded := resp.Body.(*DecodedBody)
+		if decoded != test.decoded {
+			t.Errorf("Expected decoded=%v for %s, but got %v", test.decoded, test.path, decoded)
+		}
+		if !test.decoded && !bytes.Equal(body, archive.Bytes()) {
+			t.Errorf("Expected %s to be saved as sent, but got %d bytes", test.path, len(body))
+		}
+	}
+}
+
+func TestCompressionModes(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		compression string
+		headers     map[string]string
+		expected    string
+	}{
+		{"", nil, "gzip, deflate, br"},
+		{"gzip", nil, "gzip"},
+		{"none", nil, ""},
+		{"auto", map[string]string{"Range": "bytes=10-"}, "identity"},
+	}
+
+	defer SetClientOptions(ClientOptions{})
+	for _, test := range tests {
+		if err := SetClientOptions(ClientOptions{Compression: test.compression}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := HttpRequestWithHeaders(ts.URL, test.headers)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		resp.Body.Close()
+		if got := received.Get("Accept-Encoding"); got != test.expected {
+			t.Errorf("Expected Accept-Encoding %q for %q, but got %q", test.expected, test.compression, got)
+		}
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for _, value := range []string{"auto", "gzip", "none"} {
+		if err := ParseCompression(value); err != nil {
+			t.Errorf("Unexpected error for %q: %v", value, err)
+		}
+	}
+	for _, value := range []string{"br", "", "GZIP"} {
+		if err := ParseCompression(value); err == nil {
+			t.Errorf("Expected error for %q, but got none", value)
+		}
+	}
+}
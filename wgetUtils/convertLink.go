@@ -14,8 +14,30 @@ import (
 // ConvertLinks converts external URLs in an HTML file to local paths for offline viewing.
 // It reads the HTML file, modifies the links using the modifyLinks function, and then saves the changes.
 func ConvertLinks(htmlFilePath string) {
-	htmlFilePath = removeHTTP(htmlFilePath)
+	convertFile(removeHTTP(htmlFilePath), nil)
+}
+
+// ConvertRequisiteLinks converts the links of a page saved with --page-requisites. Its
+// requisites point to the local copies as with ConvertLinks, but its anchors were not
+// downloaded, so like wget they are made absolute and keep leading to the live site.
+func ConvertRequisiteLinks(pageURL string) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		fmt.Println("Error parsing page URL:", err)
+		return
+	}
 
+	// The page was saved under its host name, without the port
+	filePath := path.Join(u.Hostname(), u.Path)
+	if u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		filePath = path.Join(filePath, "index.html")
+	}
+	convertFile(filePath, u)
+}
+
+// convertFile rewrites the links of the HTML file at htmlFilePath. When pageURL is set,
+// anchors are resolved against it instead of being pointed at local copies.
+func convertFile(htmlFilePath string, pageURL *url.URL) {
 	if !strings.HasSuffix(htmlFilePath, ".html") {
 		return
 	}
@@ -35,7 +57,7 @@ func ConvertLinks(htmlFilePath string) {
 	}
 
 	// Modify the document by converting external links to local paths
-	modifyLinks(doc, path.Dir(htmlFilePath))
+	modifyLinks(doc, path.Dir(htmlFilePath), pageURL)
 
 	// Convert the modified HTML back to string
 	var modifiedHTML strings.Builder
@@ -57,11 +79,16 @@ func ConvertLinks(htmlFilePath string) {
 
 // modifyLinks traverses an HTML node tree and modifies URLs in attributes like href, src, and style
 // to use local paths. It also converts URLs found within inline styles into local paths using convertCSSURLs.
-func modifyLinks(n *html.Node, basePath string) {
+// With an anchorBase, the hrefs of anchors are made absolute against it instead.
+func modifyLinks(n *html.Node, basePath string, anchorBase *url.URL) {
 	if n.Type == html.ElementNode {
 		for i, attr := range n.Attr {
-			if attr.Key == "href" || attr.Key == "src" {
+			if anchorBase != nil && (n.Data == "a" || n.Data == "area") && attr.Key == "href" {
+				n.Attr[i].Val = absoluteURL(anchorBase, attr.Val)
+			} else if attr.Key == "href" || attr.Key == "src" || attr.Key == "poster" || (n.Data == "object" && attr.Key == "data") {
 				n.Attr[i].Val = getLocalPath(attr.Val)
+			} else if attr.Key == "srcset" {
+				n.Attr[i].Val = convertSrcset(attr.Val)
 			} else if attr.Key == "style" {
 				n.Attr[i].Val = convertCSSURLs(attr.Val)
 			}
@@ -73,10 +100,23 @@ func modifyLinks(n *html.Node, basePath string) {
 	}
 
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		modifyLinks(c, basePath)
+		modifyLinks(c, basePath, anchorBase)
 	}
 }
 
+// absoluteURL resolves link against base. Links within the page, such as "#top", and
+// links that cannot be parsed are left as they are.
+func absoluteURL(base *url.URL, link string) string {
+	if strings.HasPrefix(link, "#") {
+		return link
+	}
+	ref, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
 // convertCSSURLs replaces all URL references in a CSS file with local file system paths.
 // This ensures that external assets referenced in stylesheets are properly mapped for offline use.
 func convertCSSURLs(cssContent string) string {
@@ -88,6 +128,21 @@ func convertCSSURLs(cssContent string) string {
 	})
 }
 
+// convertSrcset converts each URL in a srcset attribute to a local path, keeping the
+// width and density descriptors that follow them.
+func convertSrcset(srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = getLocalPath(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
 // getLocalPath converts a given URL into a local file system path.
 // It handles absolute HTTP(S) URLs, protocol-relative URLs, and root-relative paths.
 func getLocalPath(originalURL string) string {
@@ -2,6 +2,7 @@ package wgetutils
 
 import (
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -77,7 +78,8 @@ func TestModifyLinks(t *testing.T) {
 		</head>
 		<body>
 			<a href="http://example.com/page1.html">Page 1</a>
-			<img src="http://example.com/image.jpg" />
+			<img src="http://example.com/image.jpg" srcset="http://example.com/image-2x.jpg 2x" />
+			<video poster="http://example.com/poster.jpg" src="http://example.com/clip.mp4"></video>
 		</body>
 		</html>
 	`))
@@ -86,7 +88,7 @@ func TestModifyLinks(t *testing.T) {
 	}
 
 	// Modify the links
-	modifyLinks(doc, ".")
+	modifyLinks(doc, ".", nil)
 
 	// Check if links were modified correctly
 	var foundLinks []string
@@ -94,7 +96,7 @@ func TestModifyLinks(t *testing.T) {
 	walk = func(n *html.Node) {
 		if n.Type == html.ElementNode {
 			for _, attr := range n.Attr {
-				if attr.Key == "href" || attr.Key == "src" {
+				if attr.Key == "href" || attr.Key == "src" || attr.Key == "poster" || attr.Key == "srcset" {
 					foundLinks = append(foundLinks, attr.Val)
 				} else if attr.Key == "style" {
 					re := regexp.MustCompile(`url\(([^)]+)\)`)
@@ -119,6 +121,26 @@ func TestModifyLinks(t *testing.T) {
 	}
 }
 
+func TestModifyLinksAbsoluteAnchors(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<a href="../about.html">About</a><a href="#top">Top</a>` +
+		`<a href="mailto:someone@example.com">Mail</a><img src="http://example.com/blog/image.jpg">`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("http://example.com/blog/post.html")
+
+	modifyLinks(doc, ".", base)
+
+	var rendered strings.Builder
+	html.Render(&rendered, doc)
+	for _, expected := range []string{`href="http://example.com/about.html"`, `href="#top"`,
+		`href="mailto:someone@example.com"`, `src="example.com/blog/image.jpg"`} {
+		if !strings.Contains(rendered.String(), expected) {
+			t.Errorf("Expected %s in %s", expected, rendered.String())
+		}
+	}
+}
+
 func TestConvertCSSURLs(t *testing.T) {
 	// Test converting CSS URLs
 	cssContent := "body { background-image: url('http://example.com/background.jpg'); }"
@@ -130,6 +152,14 @@ func TestConvertCSSURLs(t *testing.T) {
 	}
 }
 
+func TestConvertSrcset(t *testing.T) {
+	got := convertSrcset("http://example.com/a.jpg 1x, /img/b.jpg 2x")
+	expected := "example.com/a.jpg 1x, img/b.jpg 2x"
+	if got != expected {
+		t.Errorf("Expected %s, but got %s", expected, got)
+	}
+}
+
 func TestGetLocalPath(t *testing.T) {
 	// Test converting URLs to local paths
 	tests := []struct {
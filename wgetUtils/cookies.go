@@ -0,0 +1,304 @@
+package wgetutils
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// CookieJar is an http.CookieJar that follows the RFC 6265 domain and path rules and
+// can be loaded from and saved to the Netscape cookies.txt format used by wget and curl.
+type CookieJar struct {
+	mu      sync.Mutex
+	entries map[string]*cookieEntry
+}
+
+// cookieEntry is a stored cookie together with the scope it applies to.
+type cookieEntry struct {
+	name       string
+	value      string
+	domain     string
+	path       string
+	hostOnly   bool // only sent to exactly domain, not its subdomains
+	secure     bool
+	httpOnly   bool
+	persistent bool // false for session cookies, which have no expiry
+	expires    time.Time
+}
+
+// NewCookieJar returns an empty cookie jar.
+func NewCookieJar() *CookieJar {
+	return &CookieJar{entries: make(map[string]*cookieEntry)}
+}
+
+// key identifies a cookie by its scope, so a newer cookie replaces an older one.
+func (e *cookieEntry) key() string {
+	return e.domain + ";" + e.path + ";" + e.name
+}
+
+func (e *cookieEntry) expired(now time.Time) bool {
+	return e.persistent && !e.expires.After(now)
+}
+
+// SetCookies stores the cookies received in a response from u.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u.Hostname())
+	if host == "" {
+		return
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range cookies {
+		entry, ok := newCookieEntry(host, u.Path, c, now)
+		if !ok {
+			continue
+		}
+		// An already expired cookie is how servers delete a cookie
+		if entry.expired(now) {
+			delete(j.entries, entry.key())
+			continue
+		}
+		j.entries[entry.key()] = entry
+	}
+}
+
+// newCookieEntry applies the domain, path and expiry rules to a received cookie.
+// It reports false when the cookie has to be rejected.
+func newCookieEntry(host, requestPath string, c *http.Cookie, now time.Time) (*cookieEntry, bool) {
+	entry := &cookieEntry{
+		name:     c.Name,
+		value:    c.Value,
+		domain:   host,
+		path:     c.Path,
+		hostOnly: true,
+		secure:   c.Secure,
+		httpOnly: c.HttpOnly,
+	}
+
+	if domain := canonicalHost(strings.TrimPrefix(c.Domain, ".")); domain != "" {
+		if !domainMatch(host, domain) {
+			return nil, false
+		}
+		// Never let a site set cookies for a whole public suffix such as "co.uk"
+		if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain && domain != host {
+			return nil, false
+		}
+		if domain != host || net.ParseIP(host) == nil {
+			entry.domain = domain
+			entry.hostOnly = false
+		}
+	}
+
+	if entry.path == "" || entry.path[0] != '/' {
+		entry.path = defaultCookiePath(requestPath)
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		entry.persistent, entry.expires = true, time.Unix(0, 0)
+	case c.MaxAge > 0:
+		entry.persistent, entry.expires = true, now.Add(time.Duration(c.MaxAge)*time.Second)
+	case !c.Expires.IsZero():
+		entry.persistent, entry.expires = true, c.Expires
+	}
+	return entry, true
+}
+
+// Cookies returns the cookies to send in a request to u, most specific path first.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	host := canonicalHost(u.Hostname())
+	requestPath := u.Path
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var matches []*cookieEntry
+	for key, entry := range j.entries {
+		if entry.expired(now) {
+			delete(j.entries, key)
+			continue
+		}
+		if entry.hostOnly && host != entry.domain || !entry.hostOnly && !domainMatch(host, entry.domain) {
+			continue
+		}
+		if !pathMatch(requestPath, entry.path) || entry.secure && u.Scheme != "https" {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if len(matches[a].path) != len(matches[b].path) {
+			return len(matches[a].path) > len(matches[b].path)
+		}
+		return matches[a].name < matches[b].name
+	})
+
+	cookies := make([]*http.Cookie, len(matches))
+	for i, entry := range matches {
+		cookies[i] = &http.Cookie{Name: entry.name, Value: entry.value}
+	}
+	return cookies
+}
+
+// LoadCookies reads cookies from a Netscape cookies.txt file into the jar.
+func (j *CookieJar) LoadCookies(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening cookies file:\n%v", err)
+	}
+	defer file.Close()
+
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// curl marks HttpOnly cookies with a prefix on an otherwise commented line
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("error parsing cookies file %s:\nline %d: expected 7 tab separated fields", path, lineNumber)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing cookies file %s:\nline %d: invalid expiry %q", path, lineNumber, fields[4])
+		}
+
+		entry := &cookieEntry{
+			domain:   canonicalHost(strings.TrimPrefix(fields[0], ".")),
+			hostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			path:     fields[2],
+			secure:   strings.EqualFold(fields[3], "TRUE"),
+			name:     fields[5],
+			value:    fields[6],
+			httpOnly: httpOnly,
+		}
+		if expires > 0 {
+			entry.persistent, entry.expires = true, time.Unix(expires, 0)
+		}
+		if entry.domain == "" || entry.expired(now) {
+			continue
+		}
+		j.entries[entry.key()] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading cookies file:\n%v", err)
+	}
+	return nil
+}
+
+// SaveCookies writes the jar to a Netscape cookies.txt file. Session cookies are only
+// written when keepSession is set, with an expiry of 0 as wget does.
+func (j *CookieJar) SaveCookies(path string, keepSession bool) error {
+	now := time.Now()
+
+	j.mu.Lock()
+	var entries []*cookieEntry
+	for _, entry := range j.entries {
+		if entry.expired(now) || !entry.persistent && !keepSession {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	j.mu.Unlock()
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].key() < entries[b].key()
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# Netscape HTTP Cookie File\n")
+	sb.WriteString("# Generated by wget. Edit at your own risk.\n\n")
+	for _, entry := range entries {
+		domain := entry.domain
+		if !entry.hostOnly {
+			domain = "." + domain
+		}
+		if entry.httpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		var expires int64
+		if entry.persistent {
+			expires = entry.expires.Unix()
+		}
+		fmt.Fprintf(&sb, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(!entry.hostOnly), entry.path, netscapeBool(entry.secure),
+			expires, entry.name, entry.value)
+	}
+
+	if err := os.WriteFile(path, []byte(sb.String()), 0o600); err != nil {
+		return fmt.Errorf("error saving cookies:\n%v", err)
+	}
+	return nil
+}
+
+// canonicalHost lowercases a host name and drops a trailing dot.
+func canonicalHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// domainMatch reports whether host is domain or one of its subdomains (RFC 6265 5.1.3).
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil
+}
+
+// pathMatch reports whether a cookie with cookiePath applies to requestPath (RFC 6265 5.1.4).
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+// defaultCookiePath returns the directory of the request path (RFC 6265 5.1.4).
+func defaultCookiePath(requestPath string) string {
+	i := strings.LastIndex(requestPath, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return requestPath[:i]
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
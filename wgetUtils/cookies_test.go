@@ -0,0 +1,96 @@
+package wgetutils
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cookieNames(cookies []*http.Cookie) string {
+	names := make([]string, len(cookies))
+	for i, c := range cookies {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestCookieJarScoping(t *testing.T) {
+	jar := NewCookieJar()
+	origin, _ := url.Parse("https://www.example.com/docs/page.html")
+	jar.SetCookies(origin, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "docs", Value: "3", Path: "/docs"},
+		{Name: "secure", Value: "4", Path: "/", Secure: true},
+		{Name: "other", Value: "5", Domain: "other.com"},
+		{Name: "suffix", Value: "6", Domain: "com"},
+	})
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://www.example.com/docs/a", "docs,host,domain,secure"},
+		{"http://www.example.com/docs/a", "docs,host,domain"},
+		{"https://cdn.example.com/docs/a", "domain"},
+		{"https://www.example.com/docsearch", "domain,secure"},
+		{"https://other.com/", ""},
+	}
+
+	for _, test := range tests {
+		u, _ := url.Parse(test.url)
+		if got := cookieNames(jar.Cookies(u)); got != test.expected {
+			t.Errorf("Expected cookies %q for %s, but got %q", test.expected, test.url, got)
+		}
+	}
+
+	// An expired cookie removes the stored one
+	jar.SetCookies(origin, []*http.Cookie{{Name: "domain", Domain: "example.com", Path: "/", MaxAge: -1}})
+	u, _ := url.Parse("https://cdn.example.com/")
+	if got := cookieNames(jar.Cookies(u)); got != "" {
+		t.Errorf("Expected deleted cookie to be gone, but got %q", got)
+	}
+}
+
+func TestCookieJarSaveAndLoad(t *testing.T) {
+	cookiesFile := filepath.Join(t.TempDir(), "cookies.txt")
+
+	jar := NewCookieJar()
+	origin, _ := url.Parse("https://example.com/")
+	jar.SetCookies(origin, []*http.Cookie{
+		{Name: "persistent", Value: "yes", Domain: "example.com", Expires: time.Now().Add(time.Hour)},
+		{Name: "session", Value: "tmp", HttpOnly: true},
+	})
+
+	if err := jar.SaveCookies(cookiesFile, false); err != nil {
+		t.Fatalf("Expected no error saving cookies, but got %v", err)
+	}
+	data, _ := os.ReadFile(cookiesFile)
+	if !strings.Contains(string(data), ".example.com\tTRUE\t/\tFALSE\t") || strings.Contains(string(data), "session") {
+		t.Errorf("Expected only the persistent cookie in Netscape format, but got:\n%s", data)
+	}
+
+	if err := jar.SaveCookies(cookiesFile, true); err != nil {
+		t.Fatalf("Expected no error saving cookies, but got %v", err)
+	}
+	data, _ = os.ReadFile(cookiesFile)
+	if !strings.Contains(string(data), "#HttpOnly_example.com\tFALSE\t/\tFALSE\t0\tsession\ttmp") {
+		t.Errorf("Expected the session cookie to be kept, but got:\n%s", data)
+	}
+
+	loaded := NewCookieJar()
+	if err := loaded.LoadCookies(cookiesFile); err != nil {
+		t.Fatalf("Expected no error loading cookies, but got %v", err)
+	}
+	if got := cookieNames(loaded.Cookies(origin)); got != "persistent,session" {
+		t.Errorf("Expected both cookies after loading, but got %q", got)
+	}
+	sub, _ := url.Parse("https://www.example.com/")
+	if got := cookieNames(loaded.Cookies(sub)); got != "persistent" {
+		t.Errorf("Expected only the domain cookie for a subdomain, but got %q", got)
+	}
+}
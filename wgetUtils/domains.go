@@ -0,0 +1,46 @@
+package wgetutils
+
+import "strings"
+
+// DomainFilter decides which other hosts a mirror may download from when it spans hosts.
+type DomainFilter struct {
+	Domains []string // -D: allowed domains, each including its subdomains; empty allows any
+	Exclude []string // --exclude-domains: domains never to download from
+}
+
+// ParseDomainList splits a comma-separated -D or --exclude-domains value.
+func ParseDomainList(value string) []string {
+	var domains []string
+	for _, domain := range strings.Split(value, ",") {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// MatchesDomain reports whether host is domain itself or one of its subdomains, so
+// "example.com" matches "cdn.example.com" but not "badexample.com".
+func MatchesDomain(host, domain string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// Allows reports whether host passes the allowlist and is not excluded.
+func (f DomainFilter) Allows(host string) bool {
+	for _, domain := range f.Exclude {
+		if MatchesDomain(host, domain) {
+			return false
+		}
+	}
+	if len(f.Domains) == 0 {
+		return true
+	}
+	for _, domain := range f.Domains {
+		if MatchesDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
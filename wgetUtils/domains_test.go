@@ -0,0 +1,44 @@
+package wgetutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDomainList(t *testing.T) {
+	got := ParseDomainList(" Example.com, .cdn.example.org,, ")
+	expected := []string{"example.com", "cdn.example.org"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
+
+func TestDomainFilter(t *testing.T) {
+	filter := DomainFilter{
+		Domains: []string{"example.com"},
+		Exclude: []string{"ads.example.com"},
+	}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"example.com", true},
+		{"cdn.example.com", true},
+		{"WWW.Example.com", true},
+		{"ads.example.com", false},
+		{"x.ads.example.com", false},
+		{"badexample.com", false},
+		{"example.org", false},
+	}
+	for _, test := range tests {
+		if got := filter.Allows(test.host); got != test.expected {
+			t.Errorf("Expected Allows(%q) to be %v, but got %v", test.host, test.expected, got)
+		}
+	}
+
+	// Without an allowlist every host that is not excluded passes
+	if !(DomainFilter{}).Allows("anything.net") {
+		t.Errorf("Expected an empty filter to allow any host")
+	}
+}
@@ -0,0 +1,81 @@
+package wgetutils
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultFileName is used when neither the server nor the URL suggests a usable name.
+const defaultFileName = "index.html"
+
+// maxFileNameLength keeps names within the limit of common file systems.
+const maxFileNameLength = 255
+
+// FileNameFromURL returns the name to save a URL under: the last segment of its path,
+// without the query string, or index.html for a directory such as "/docs/".
+func FileNameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return defaultFileName
+	}
+	if strings.HasSuffix(u.Path, "/") {
+		return defaultFileName
+	}
+	if name := SanitizeFileName(path.Base(u.Path)); name != "" {
+		return name
+	}
+	return defaultFileName
+}
+
+// FileNameFromHeader returns the file name suggested by a Content-Disposition header,
+// preferring the RFC 5987 filename* parameter, or "" when there is no usable name.
+func FileNameFromHeader(header http.Header) string {
+	value := header.Get("Content-Disposition")
+	if value == "" {
+		return ""
+	}
+	// mime decodes filename*=UTF-8''... into the plain filename parameter
+	_, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return SanitizeFileName(params["filename"])
+}
+
+// SanitizeFileName reduces a name suggested by a server to a plain file name, so it
+// cannot escape the download directory, create a hidden file or contain control
+// characters. It returns "" when nothing usable is left.
+func SanitizeFileName(name string) string {
+	// Only keep the last path element, whichever separator the server used
+	if i := strings.LastIndexAny(name, `/\`); i != -1 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+
+	if len(name) > maxFileNameLength {
+		// Cut on a rune boundary, keeping the extension where possible
+		ext := path.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:maxFileNameLength-len(ext)]
+		for len(base) > 0 {
+			if r, size := utf8.DecodeLastRuneInString(base); r != utf8.RuneError || size > 1 {
+				break
+			}
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+	return name
+}
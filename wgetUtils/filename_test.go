@@ -0,0 +1,61 @@
+package wgetutils
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFileNameFromURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/files/report.pdf", "report.pdf"},
+		{"https://example.com/download?id=42", "download"},
+		{"https://example.com/docs/", "index.html"},
+		{"https://example.com", "index.html"},
+		{"https://example.com/my%20file.txt", "my file.txt"},
+		{"https://example.com/..", "index.html"},
+	}
+
+	for _, test := range tests {
+		if result := FileNameFromURL(test.url); result != test.expected {
+			t.Errorf("Expected %q for %s, but got %q", test.expected, test.url, result)
+		}
+	}
+}
+
+func TestFileNameFromHeader(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{`attachment; filename="report.csv"`, "report.csv"},
+		{`attachment; filename*=UTF-8''%E2%82%AC%20rates.csv`, "€ rates.csv"},
+		{`attachment; filename="plain.csv"; filename*=UTF-8''fancy.csv`, "fancy.csv"},
+		{`attachment; filename="../../etc/passwd"`, "passwd"},
+		{`attachment; filename="..\\..\\boot.ini"`, "boot.ini"},
+		{`attachment; filename=".bashrc"`, "bashrc"},
+		{`attachment; filename=".."`, ""},
+		{`inline`, ""},
+		{``, ""},
+	}
+
+	for _, test := range tests {
+		header := http.Header{}
+		if test.header != "" {
+			header.Set("Content-Disposition", test.header)
+		}
+		if result := FileNameFromHeader(header); result != test.expected {
+			t.Errorf("Expected %q for %s, but got %q", test.expected, test.header, result)
+		}
+	}
+}
+
+func TestSanitizeFileNameLength(t *testing.T) {
+	name := SanitizeFileName(strings.Repeat("é", 200) + ".tar.gz")
+	if len(name) > maxFileNameLength || !strings.HasSuffix(name, ".gz") {
+		t.Errorf("Expected a name of at most %d bytes keeping its extension, but got %d bytes: %q", maxFileNameLength, len(name), name)
+	}
+}
@@ -0,0 +1,203 @@
+package wgetutils
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// URLFilter decides which URLs a mirror visits and saves. The options are set from the
+// command line as given, then Compile turns them into a pipeline of stages that every
+// URL goes through in order until one of them rejects it.
+type URLFilter struct {
+	Accept      string // -A: comma-separated file name suffixes or shell globs to keep
+	Reject      string // -R: comma-separated file name suffixes or shell globs to drop
+	IncludeDirs string // -I: comma-separated directories to stay within
+	ExcludeDirs string // -X: comma-separated directories to stay out of
+	AcceptRegex string // --accept-regex: matched against the full URL
+	RejectRegex string // --reject-regex: matched against the full URL
+	IgnoreCase  bool   // --ignore-case: applies to all of the above
+
+	stages []filterStage
+}
+
+// filterStage is one step of the pipeline. Stages marked fileOnly look at the file name
+// and only apply to files about to be saved, since pages have to be fetched anyway to
+// find their links.
+type filterStage struct {
+	option   string
+	fileOnly bool
+	passes   func(u *url.URL, raw string) bool
+}
+
+// Compile validates the options and builds the pipeline. It must be called before Check.
+func (f *URLFilter) Compile() error {
+	f.stages = nil
+	fold := func(s string) string {
+		if f.IgnoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+
+	if dirs := splitList(fold(f.IncludeDirs), normalizeDirectory); len(dirs) > 0 {
+		f.stages = append(f.stages, filterStage{"--include-directories", false, func(u *url.URL, _ string) bool {
+			return matchesDirectory(fold(urlDirectory(u)), dirs)
+		}})
+	}
+	if dirs := splitList(fold(f.ExcludeDirs), normalizeDirectory); len(dirs) > 0 {
+		f.stages = append(f.stages, filterStage{"--exclude", false, func(u *url.URL, _ string) bool {
+			return !matchesDirectory(fold(urlDirectory(u)), dirs)
+		}})
+	}
+
+	for _, option := range []struct {
+		name, expr string
+		accept     bool
+	}{{"--accept-regex", f.AcceptRegex, true}, {"--reject-regex", f.RejectRegex, false}} {
+		if option.expr == "" {
+			continue
+		}
+		expr := option.expr
+		if f.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid %s value:\n%v", option.name, err)
+		}
+		accept := option.accept
+		f.stages = append(f.stages, filterStage{option.name, false, func(_ *url.URL, raw string) bool {
+			return re.MatchString(raw) == accept
+		}})
+	}
+
+	if names := splitList(fold(f.Accept), strings.TrimSpace); len(names) > 0 {
+		f.stages = append(f.stages, filterStage{"--accept", true, func(_ *url.URL, raw string) bool {
+			return matchesFileName(fold(FileNameFromURL(raw)), names)
+		}})
+	}
+	if names := splitList(fold(f.Reject), strings.TrimSpace); len(names) > 0 {
+		f.stages = append(f.stages, filterStage{"--reject", true, func(_ *url.URL, raw string) bool {
+			return !matchesFileName(fold(FileNameFromURL(raw)), names)
+		}})
+	}
+
+	// Catch malformed globs now rather than silently matching nothing later
+	for _, glob := range splitList(strings.Join([]string{f.Accept, f.Reject, f.IncludeDirs, f.ExcludeDirs}, ","), strings.TrimSpace) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q:\n%v", glob, err)
+		}
+	}
+	return nil
+}
+
+// Check runs rawURL through the whole pipeline, for a file about to be saved. It returns
+// nil when the URL passes, or an error naming the option that rejected it.
+func (f *URLFilter) Check(rawURL string) error {
+	return f.check(rawURL, true)
+}
+
+// CheckTraversal runs only the directory and regex stages, which decide whether a URL is
+// visited at all.
+func (f *URLFilter) CheckTraversal(rawURL string) error {
+	return f.check(rawURL, false)
+}
+
+func (f *URLFilter) check(rawURL string, file bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	for _, stage := range f.stages {
+		if stage.fileOnly && !file {
+			continue
+		}
+		if !stage.passes(u, rawURL) {
+			return fmt.Errorf("rejected by %s", stage.option)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated option value, normalizing each entry.
+func splitList(value string, normalize func(string) string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = normalize(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// normalizeDirectory turns "docs/" into "/docs" so entries compare with URL paths.
+func normalizeDirectory(dir string) string {
+	dir = strings.Trim(strings.TrimSpace(dir), "/")
+	if dir == "" {
+		return ""
+	}
+	return "/" + dir
+}
+
+// urlDirectory returns the directory part of a URL path, e.g. "/docs/api" for
+// "/docs/api/index.html" or "/docs/api/".
+func urlDirectory(u *url.URL) string {
+	if strings.HasSuffix(u.Path, "/") {
+		return path.Clean(u.Path)
+	}
+	return path.Dir("/" + strings.TrimPrefix(u.Path, "/"))
+}
+
+// StartDirectory returns the directory of rawURL's path, which --no-parent keeps a
+// mirror within: "/v2" for both "/v2/" and "/v2/index.html".
+func StartDirectory(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "/"
+	}
+	return urlDirectory(u)
+}
+
+// IsUnderDirectory reports whether rawURL's path lies within dir. The path is decoded and
+// cleaned first, so "..", "%2e%2e" or doubled slashes cannot step outside of dir.
+func IsUnderDirectory(rawURL, dir string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	p := path.Clean("/" + u.Path)
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// matchesDirectory reports whether dir, or any directory above it, matches one of the
+// entries. Entries may use shell globs, so "/docs/*" matches "/docs/api/v2".
+func matchesDirectory(dir string, entries []string) bool {
+	for candidate := dir; ; candidate = path.Dir(candidate) {
+		for _, entry := range entries {
+			if matched, _ := path.Match(entry, candidate); matched {
+				return true
+			}
+		}
+		if candidate == "/" || candidate == "." {
+			return false
+		}
+	}
+}
+
+// matchesFileName reports whether name matches one of the entries: a shell glob when it
+// contains a wildcard, otherwise a suffix such as "pdf" or ".tar.gz".
+func matchesFileName(name string, entries []string) bool {
+	for _, entry := range entries {
+		if strings.ContainsAny(entry, "*?[") {
+			if matched, _ := path.Match(entry, name); matched {
+				return true
+			}
+		} else if strings.HasSuffix(name, entry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package wgetutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestURLFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   URLFilter
+		url      string
+		expected string // the option expected to reject the URL, "" if it passes
+	}{
+		{"no filters", URLFilter{}, "http://example.com/file.pdf", ""},
+		{"reject suffix", URLFilter{Reject: "pdf"}, "http://example.com/file.pdf", "--reject"},
+		{"reject other suffix", URLFilter{Reject: "pdf"}, "http://example.com/file.txt", ""},
+		{"reject glob", URLFilter{Reject: "thumb-*.jpg"}, "http://example.com/img/thumb-1.jpg", "--reject"},
+		{"accept suffix", URLFilter{Accept: "jpg,png"}, "http://example.com/img/a.png", ""},
+		{"accept misses", URLFilter{Accept: "jpg,png"}, "http://example.com/doc.html", "--accept"},
+		{"accept glob", URLFilter{Accept: "report-20??.pdf"}, "http://example.com/report-2024.pdf", ""},
+		{"accept case", URLFilter{Accept: "jpg"}, "http://example.com/A.JPG", "--accept"},
+		{"accept ignore case", URLFilter{Accept: "jpg", IgnoreCase: true}, "http://example.com/A.JPG", ""},
+		{"exclude directory", URLFilter{ExcludeDirs: "/path/to"}, "http://example.com/path/to/file", "--exclude"},
+		{"exclude subdirectory", URLFilter{ExcludeDirs: "/path/to"}, "http://example.com/path/to/deeper/file", "--exclude"},
+		{"exclude is not a substring", URLFilter{ExcludeDirs: "/path/to"}, "http://example.com/path/to2/file", ""},
+		{"exclude elsewhere in path", URLFilter{ExcludeDirs: "/to"}, "http://example.com/path/to/file", ""},
+		{"exclude glob", URLFilter{ExcludeDirs: "/*/private"}, "http://example.com/team/private/a.html", "--exclude"},
+		{"include directory", URLFilter{IncludeDirs: "docs,/blog/"}, "http://example.com/blog/post.html", ""},
+		{"include misses", URLFilter{IncludeDirs: "/docs"}, "http://example.com/shop/item.html", "--include-directories"},
+		{"include ignore case", URLFilter{IncludeDirs: "/docs", IgnoreCase: true}, "http://example.com/Docs/", ""},
+		{"accept regex", URLFilter{AcceptRegex: `/v[0-9]+/`}, "http://example.com/api/v2/index.html", ""},
+		{"accept regex misses", URLFilter{AcceptRegex: `/v[0-9]+/`}, "http://example.com/api/latest/", "--accept-regex"},
+		{"reject regex on query", URLFilter{RejectRegex: `[?&]sort=`}, "http://example.com/list?page=2&sort=asc", "--reject-regex"},
+		{"reject regex case", URLFilter{RejectRegex: `logout`, IgnoreCase: true}, "http://example.com/LogOut", "--reject-regex"},
+		// Directories are checked first, so they are the ones reported
+		{"pipeline order", URLFilter{ExcludeDirs: "/tmp", Reject: "pdf"}, "http://example.com/tmp/a.pdf", "--exclude"},
+	}
+
+	for _, test := range tests {
+		if err := test.filter.Compile(); err != nil {
+			t.Fatalf("%s: expected no error, but got %v", test.name, err)
+		}
+		err := test.filter.Check(test.url)
+		if test.expected == "" && err != nil {
+			t.Errorf("%s: expected %s to pass, but got %v", test.name, test.url, err)
+		} else if test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+			t.Errorf("%s: expected %s to be rejected by %s, but got %v", test.name, test.url, test.expected, err)
+		}
+	}
+}
+
+func TestURLFilterTraversal(t *testing.T) {
+	filter := URLFilter{Accept: "jpg", ExcludeDirs: "/private"}
+	if err := filter.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pages are visited for their links even when their names are not accepted
+	if err := filter.CheckTraversal("http://example.com/gallery.html"); err != nil {
+		t.Errorf("Expected the page to be visited, but got %v", err)
+	}
+	if err := filter.CheckTraversal("http://example.com/private/gallery.html"); err == nil {
+		t.Errorf("Expected the excluded directory not to be visited")
+	}
+}
+
+func TestURLFilterCompileErrors(t *testing.T) {
+	for _, filter := range []URLFilter{{AcceptRegex: "("}, {RejectRegex: "[a-"}, {Accept: "[a-"}, {ExcludeDirs: "/a/[b"}} {
+		if err := filter.Compile(); err == nil {
+			t.Errorf("Expected an error for %+v, but got none", filter)
+		}
+	}
+}
+
+func TestIsUnderDirectory(t *testing.T) {
+	dir := StartDirectory("https://docs.example.com/v2/")
+	if dir != "/v2" {
+		t.Fatalf("Expected start directory /v2, but got %s", dir)
+	}
+	if got := StartDirectory("https://docs.example.com/v2/index.html"); got != "/v2" {
+		t.Errorf("Expected start directory /v2, but got %s", got)
+	}
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://docs.example.com/v2/", true},
+		{"https://docs.example.com/v2", true},
+		{"https://docs.example.com/v2/api/index.html", true},
+		{"https://docs.example.com/v1/", false},
+		{"https://docs.example.com/v20/", false},
+		{"https://docs.example.com/blog/post.html", false},
+		{"https://docs.example.com/v2/../v1/", false},
+		{"https://docs.example.com/v2/%2e%2e/v1/", false},
+		{"https://docs.example.com//v2//api/", true},
+	}
+	for _, test := range tests {
+		if got := IsUnderDirectory(test.url, dir); got != test.expected {
+			t.Errorf("Expected IsUnderDirectory(%s, %s) to be %v, but got %v", test.url, dir, test.expected, got)
+		}
+	}
+}
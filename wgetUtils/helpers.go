@@ -1,7 +1,10 @@
 package wgetutils
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"net/url"
@@ -9,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ValidateURL checks if the given link is a valid URL.
@@ -29,119 +33,239 @@ func ExtractDomain(urls string) (string, error) {
 	return u.Hostname(), nil
 }
 
-// IsRejected checks if the URL ends with any of the rejected file types based on the provided rejectTypes string.
-func IsRejected(url, rejectTypes string) bool {
-	if rejectTypes == "" {
-		return false
-	}
+// HttpRequest sends an HTTP GET request to the provided URL with custom headers
+// to simulate a browser request.
+func HttpRequest(url string) (*http.Response, error) {
+	return HttpRequestWithHeaders(url, nil)
+}
+
+// HttpRequestWithHeaders sends an HTTP GET request like HttpRequest, adding the
+// given headers on top of the browser defaults (e.g. a Range header when resuming).
+func HttpRequestWithHeaders(url string, headers map[string]string) (*http.Response, error) {
+	return doRequest("GET", url, nil, headers)
+}
+
+// HttpRequestWithBody sends a request with the given method and body, as used by --method,
+// --post-data and --post-file. The body is sent again on retries and 307/308 redirects.
+func HttpRequestWithBody(method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	return doRequest(method, url, body, headers)
+}
 
-	rejectedTypes := strings.Split(rejectTypes, ",")
-	for _, ext := range rejectedTypes {
-		if strings.HasSuffix(url, ext) {
-			return true
+// HttpHeadRequest sends an HTTP HEAD request to the provided URL, used to inspect
+// the size and range support of a file before downloading it.
+func HttpHeadRequest(url string) (*http.Response, error) {
+	return doRequest("HEAD", url, nil, nil)
+}
+
+// doRequest builds and sends a request with the browser-like default headers,
+// retrying transient failures and retryable statuses according to the retry policy.
+func doRequest(method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	policy := CurrentRetryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := sendRequest(method, url, body, headers)
+		if err == nil && !policy.ShouldRetryStatus(resp.StatusCode) {
+			return resp, nil
 		}
+		if !policy.CanRetry(attempt) || (err != nil && !isTransient(err)) {
+			return resp, err
+		}
+
+		// Prefer the server's Retry-After hint over our own backoff, within --waitretry
+		wait, ok := RetryAfter(resp)
+		if !ok {
+			wait = policy.Backoff(attempt)
+		} else if wait > policy.WaitRetry {
+			wait = policy.WaitRetry
+		}
+		if err != nil {
+			fmt.Printf("%v\nretrying in %s (attempt %d)\n", err, wait.Round(time.Millisecond), attempt+1)
+		} else {
+			fmt.Printf("status %s\nretrying in %s (attempt %d)\n", resp.Status, wait.Round(time.Millisecond), attempt+1)
+			resp.Body.Close()
+		}
+		time.Sleep(wait)
 	}
-	return false
 }
 
-// HttpRequest sends an HTTP GET request to the provided URL with custom headers
-// to simulate a browser request.
-func HttpRequest(url string) (*http.Response, error) {
-	// Create a new HTTP client
-	client := &http.Client{}
-
-	// Create a new request with a User-Agent header
-	req, err := http.NewRequest("GET", url, nil)
+// sendRequest sends a single request with the default and user-supplied headers. When
+// credentials are configured it answers a 401 challenge from the original host once.
+func sendRequest(method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := newRequest(method, url, body, headers)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
+		return nil, err
 	}
 
-	// Set headers to mimic a Chrome browser
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.85 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Connection", "keep-alive")
+	// With --auth-no-challenge, send Basic credentials without waiting to be asked
+	user, password := credentialsFor(req.URL.Hostname())
+	if user != "" && clientOptions.AuthNoChallenge {
+		req.Header.Set("Authorization", basicAuthorization(user, password))
+	}
 
-	// Send the request
-	resp, err := client.Do(req)
+	// Send the request through the shared client
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+		return nil, fmt.Errorf("error sending request: %v", describeTimeout(err))
+	}
+
+	// Only answer challenges from the host the user asked for, never from a redirect target
+	if resp.StatusCode == http.StatusUnauthorized && user != "" && resp.Request.URL.Host == req.URL.Host {
+		retry, err := newRequest(resp.Request.Method, resp.Request.URL.String(), requestBody(resp.Request, body), headers)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		if authorization := authorizationFor(resp, retry, user, password); authorization != "" {
+			resp.Body.Close()
+			retry.Header.Set("Authorization", authorization)
+			resp, err = httpClient.Do(retry)
+			if err != nil {
+				return nil, fmt.Errorf("error sending request: %v", describeTimeout(err))
+			}
+		}
 	}
 
+	resp.Body = newTimeoutBody(resp.Body, clientOptions.ReadTimeout)
+	decodeBody(resp)
 	return resp, err
 }
 
-// isValidAttribute checks if an HTML tag attribute is valid for processing
-func IsValidAttribute(tagName, attrKey string) bool {
-	return (tagName == "link" && attrKey == "href") ||
-		(tagName == "a" && attrKey == "href") ||
-		(tagName == "script" && attrKey == "src") ||
-		(tagName == "img" && attrKey == "src")
+// newRequest creates a request with the browser-like defaults and the given extra headers.
+// A non-nil body is sent with a Content-Type guessed from its contents, which a --header
+// can override.
+func newRequest(method, url string, body []byte, headers map[string]string) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", bodyContentType(body))
+	}
+
+	// Set headers to mimic a Chrome browser, plus any user-supplied overrides
+	applyHeaders(req)
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	// Byte ranges and the sizes reported by HEAD refer to the uncompressed file, so keep
+	// those requests uncompressed
+	if req.Header.Get("Range") != "" || method == "HEAD" {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	return req, nil
 }
 
-// ResolveURL resolves a relative URL to an absolute URL based on the given base URL.
-// It handles fragment identifiers, protocols, and relative paths (e.g., './', '/', etc.).
-func ResolveURL(base, rel string) string {
-	// Remove fragment identifiers (anything starting with #)
-	if fragmentIndex := strings.Index(rel, "#"); fragmentIndex != -1 {
-		rel = rel[:fragmentIndex]
+// bodyContentType returns application/json for a JSON object or array, and the form
+// encoding wget uses for --post-data otherwise.
+func bodyContentType(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
 	}
+	return "application/x-www-form-urlencoded"
+}
 
-	if strings.HasPrefix(rel, "http") {
-		return rel
+// requestBody returns the body to send again with req, which is nil once a redirect
+// has turned the request into a GET.
+func requestBody(req *http.Request, body []byte) []byte {
+	if req.Method == "GET" || req.Method == "HEAD" {
+		return nil
 	}
+	return body
+}
 
-	if strings.HasPrefix(rel, "//") {
-		protocol := "http:"
-		if strings.HasPrefix(base, "https") {
-			protocol = "https:"
-		}
-		return protocol + rel
+// ParseContentRange parses a "Content-Range: bytes start-end/total" header value.
+// The total is -1 when the server reports it as unknown ("*").
+func ParseContentRange(value string) (start, total int64, err error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "bytes ") {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", value)
 	}
+	value = strings.TrimPrefix(value, "bytes ")
 
-	if strings.HasPrefix(rel, "/") {
-		return strings.Join(strings.Split(base, "/")[:3], "/") + rel
+	rangePart, totalPart, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", value)
 	}
-	if strings.HasPrefix(rel, "./") {
-		return strings.Join(strings.Split(base, "/")[:3], "/") + rel[1:]
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", value)
 	}
-	if strings.HasPrefix(rel, "//") && strings.Contains(rel[2:], "/") {
-		baseParts := strings.Split(base, "/")
-		return baseParts[0] + "//" + baseParts[2] + rel[1:]
+
+	start, err = strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range start: %v", err)
 	}
+	if totalPart == "*" {
+		return start, -1, nil
+	}
+	total, err = strconv.ParseInt(totalPart, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range total: %v", err)
+	}
+	return start, total, nil
+}
 
-	baseParts := strings.Split(base, "/")
-	return baseParts[0] + "//" + baseParts[2] + "/" + rel
+// isValidAttribute checks if an HTML tag attribute is valid for processing: links,
+// and the stylesheets, scripts, images, fonts and media a page needs to render
+func IsValidAttribute(tagName, attrKey string) bool {
+	return (tagName == "link" && attrKey == "href") ||
+		(tagName == "a" && attrKey == "href") ||
+		(tagName == "script" && attrKey == "src") ||
+		(tagName == "img" && (attrKey == "src" || attrKey == "srcset")) ||
+		(tagName == "source" && (attrKey == "src" || attrKey == "srcset")) ||
+		((tagName == "video" || tagName == "audio" || tagName == "track") && attrKey == "src") ||
+		(tagName == "video" && attrKey == "poster") ||
+		((tagName == "embed" || tagName == "iframe" || tagName == "input") && attrKey == "src") ||
+		(tagName == "object" && attrKey == "data")
 }
 
-// IsRejectedPath checks if the given URL contains any path specified in the pathRejects string.
-func IsRejectedPath(url, pathRejects string) bool {
-	if pathRejects == "" {
-		return false
+// ResolveURL resolves a link found on the page at base into an absolute URL. It follows
+// RFC 3986, so relative paths resolve against the page's own directory and "." and ".."
+// segments are removed. Fragments are dropped, and "" is returned for unparsable links.
+func ResolveURL(base, rel string) string {
+	// Remove fragment identifiers (anything starting with #)
+	if fragmentIndex := strings.Index(rel, "#"); fragmentIndex != -1 {
+		rel = rel[:fragmentIndex]
 	}
 
-	rejects := strings.Split(pathRejects, ",")
-	for _, path := range rejects {
-		if path[0] != '/' {
-			continue
-		}
-		if contains(url, path) {
-			return true
-		}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
 	}
+	relURL, err := url.Parse(strings.TrimSpace(rel))
+	if err != nil {
+		return ""
+	}
+	return baseURL.ResolveReference(relURL).String()
+}
 
-	return false
+// IsWebURL reports whether rawURL is an http or https URL with a host, the only kind a
+// mirror can fetch. Links such as mailto:, javascript: or tel: are not.
+func IsWebURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
 
-// contains checks if a string contains a specified substring.
-// It performs a simple substring search by comparing slices of the string.
-func contains(str, substr string) bool {
-	for i := 0; i < len(str)-len(substr); i++ {
-		if str[i:i+len(substr)] == substr {
-			return true
-		}
+// ParseLevel parses a --level value, the number of links to follow from the start URL.
+// "inf" and 0 both mean there is no limit and are returned as 0.
+func ParseLevel(value string) (int, error) {
+	if value == "inf" {
+		return 0, nil
 	}
-	return false
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 {
+		return 0, fmt.Errorf("invalid --level value.\nUsage: --level=5 or --level=inf")
+	}
+	return level, nil
 }
 
 // ExpandPath expands shorthand notations to full paths
@@ -228,4 +352,4 @@ func SaveProgressState(tempConfigFile string, showProgress bool) error {
 		return fmt.Errorf("error saving showProgress state: %v", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -54,25 +54,6 @@ func TestExtractDomain(t *testing.T) {
 	}
 }
 
-func TestIsRejected(t *testing.T) {
-	tests := []struct {
-		url         string
-		rejectTypes string
-		expected    bool
-	}{
-		{"http://example.com/file.pdf", "pdf", true},
-		{"http://example.com/file.txt", "pdf", false},
-		{"http://example.com/file.pdf", "", false},
-	}
-
-	for _, test := range tests {
-		rejected := IsRejected(test.url, test.rejectTypes)
-		if rejected != test.expected {
-			t.Errorf("Expected rejection status %v for %s with reject types %s, but got %v", test.expected, test.url, test.rejectTypes, rejected)
-		}
-	}
-}
-
 func TestHttpRequest(t *testing.T) {
 	// Setup a test server
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -93,6 +74,106 @@ func TestHttpRequest(t *testing.T) {
 	defer resp.Body.Close()
 }
 
+func TestHttpRequestWithBody(t *testing.T) {
+	type received struct {
+		method, contentType, body string
+		contentLength             int64
+	}
+	var got received
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = received{r.Method, r.Header.Get("Content-Type"), string(body), r.ContentLength}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		method   string
+		body     string
+		expected received
+	}{
+		{"POST", "name=report&format=csv", received{"POST", "application/x-www-form-urlencoded", "name=report&format=csv", 22}},
+		{"POST", `{"format": "csv"}`, received{"POST", "application/json", `{"format": "csv"}`, 17}},
+		{"PUT", "", received{"PUT", "application/x-www-form-urlencoded", "", 0}},
+	}
+
+	for _, test := range tests {
+		resp, err := HttpRequestWithBody(test.method, ts.URL, []byte(test.body), nil)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		resp.Body.Close()
+		if got != test.expected {
+			t.Errorf("Expected %+v, but got %+v", test.expected, got)
+		}
+	}
+}
+
+func TestHttpRequestWithBodyRedirects(t *testing.T) {
+	var method, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/final" {
+			status := 0
+			fmt.Sscanf(r.URL.Path, "/%d", &status)
+			http.Redirect(w, r, "/final", status)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		method, body = r.Method, string(data)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		method         string
+		status         int
+		expectedMethod string
+		expectedBody   string
+	}{
+		{"POST", http.StatusFound, "GET", ""},
+		{"POST", http.StatusMovedPermanently, "GET", ""},
+		{"PUT", http.StatusFound, "PUT", "data"},
+		{"PUT", http.StatusSeeOther, "GET", ""},
+		{"POST", http.StatusTemporaryRedirect, "POST", "data"},
+		{"POST", http.StatusPermanentRedirect, "POST", "data"},
+	}
+
+	for _, test := range tests {
+		resp, err := HttpRequestWithBody(test.method, fmt.Sprintf("%s/%d", ts.URL, test.status), []byte("data"), nil)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		resp.Body.Close()
+		if method != test.expectedMethod || body != test.expectedBody {
+			t.Errorf("Expected %s %q after %s and %d, but got %s %q",
+				test.expectedMethod, test.expectedBody, test.method, test.status, method, body)
+		}
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value       string
+		start       int64
+		total       int64
+		expectedErr bool
+	}{
+		{"bytes 100-199/200", 100, 200, false},
+		{"bytes 0-99/*", 0, -1, false},
+		{"bytes */200", 0, 0, true},
+		{"items 0-1/2", 0, 0, true},
+	}
+
+	for _, test := range tests {
+		start, total, err := ParseContentRange(test.value)
+		if err != nil && !test.expectedErr {
+			t.Errorf("Expected no error for %s, but got %v", test.value, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %s, but got none", test.value)
+		} else if err == nil && (start != test.start || total != test.total) {
+			t.Errorf("Expected %d/%d for %s, but got %d/%d", test.start, test.total, test.value, start, total)
+		}
+	}
+}
+
 func TestIsValidAttribute(t *testing.T) {
 	tests := []struct {
 		tagName  string
@@ -103,7 +184,13 @@ func TestIsValidAttribute(t *testing.T) {
 		{"a", "href", true},
 		{"script", "src", true},
 		{"img", "src", true},
+		{"img", "srcset", true},
+		{"source", "srcset", true},
+		{"video", "poster", true},
+		{"audio", "src", true},
+		{"object", "data", true},
 		{"div", "href", false},
+		{"div", "srcset", false},
 	}
 
 	for _, test := range tests {
@@ -124,6 +211,11 @@ func TestResolveURL(t *testing.T) {
 		{"http://example.com", "./path/to/file", "http://example.com/path/to/file"},
 		{"http://example.com", "http://example2.com/path/to/file", "http://example2.com/path/to/file"},
 		{"http://example.com", "//example2.com/path/to/file", "http://example2.com/path/to/file"},
+		{"http://example.com/docs/v2/intro.html", "setup.html", "http://example.com/docs/v2/setup.html"},
+		{"http://example.com/docs/v2/", "./api/#top", "http://example.com/docs/v2/api/"},
+		{"http://example.com/docs/v2/intro.html", "../v1/intro.html", "http://example.com/docs/v1/intro.html"},
+		{"http://example.com/docs/", "../../../etc/passwd", "http://example.com/etc/passwd"},
+		{"https://example.com/a/b", "?page=2", "https://example.com/a/b?page=2"},
 	}
 
 	for _, test := range tests {
@@ -134,39 +226,24 @@ func TestResolveURL(t *testing.T) {
 	}
 }
 
-func TestIsRejectedPath(t *testing.T) {
-	tests := []struct {
-		url         string
-		pathRejects string
-		expected    bool
-	}{
-		{"http://example.com/path/to/file", "/path/to", true},
-		{"http://example.com/path/to/file", "/path/to2", false},
-		{"http://example.com/path/to/file", "", false},
-	}
-
-	for _, test := range tests {
-		rejected := IsRejectedPath(test.url, test.pathRejects)
-		if rejected != test.expected {
-			t.Errorf("Expected rejection status %v for %s with path rejects %s, but got %v", test.expected, test.url, test.pathRejects, rejected)
-		}
-	}
-}
-
-func TestContains(t *testing.T) {
+func TestIsWebURL(t *testing.T) {
 	tests := []struct {
-		str      string
-		substr   string
+		url      string
 		expected bool
 	}{
-		{"hello world", "world", false},
-		{"hello world", "goodbye", false},
+		{"http://example.com/page.html", true},
+		{"https://example.com", true},
+		{"mailto:someone@example.com", false},
+		{"javascript:void(0)", false},
+		{"tel:+15551234567", false},
+		{"ftp://example.com/file", false},
+		{"http:///no-host", false},
+		{"", false},
 	}
 
 	for _, test := range tests {
-		containsResult := contains(test.str, test.substr)
-		if containsResult != test.expected {
-			t.Errorf("Expected %v for contains(%s, %s), but got %v", test.expected, test.str, test.substr, containsResult)
+		if got := IsWebURL(test.url); got != test.expected {
+			t.Errorf("Expected IsWebURL(%q) to be %v, but got %v", test.url, test.expected, got)
 		}
 	}
 }
@@ -326,3 +403,26 @@ func TestSaveProgressState(t *testing.T) {
 	}
 	// data, err := ioutil.ReadFile(tempConfigFile)
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+		valid    bool
+	}{
+		{"5", 5, true},
+		{"0", 0, true},
+		{"inf", 0, true},
+		{"-1", 0, false},
+		{"deep", 0, false},
+	}
+
+	for _, test := range tests {
+		level, err := ParseLevel(test.value)
+		if test.valid && (err != nil || level != test.expected) {
+			t.Errorf("Expected %d for %q, but got %d (%v)", test.expected, test.value, level, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("Expected error for %q, but got none", test.value)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package wgetutils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// MetalinkMediaType is the Content-Type of Metalink v4 documents (RFC 5854).
+const MetalinkMediaType = "application/metalink4+xml"
+
+// Metalink is a parsed Metalink v4 document describing one or more files.
+type Metalink struct {
+	Files []MetalinkFile `xml:"file"`
+}
+
+// MetalinkFile is one file in a Metalink document, with its mirrors and digests.
+type MetalinkFile struct {
+	Name   string         `xml:"name,attr"`
+	Size   int64          `xml:"size"`
+	Hashes []MetalinkHash `xml:"hash"`
+	URLs   []MetalinkURL  `xml:"url"`
+}
+
+// MetalinkHash is a whole-file digest, with the type named as in the IANA registry.
+type MetalinkHash struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MetalinkURL is one mirror of a file. A lower priority value is tried first.
+type MetalinkURL struct {
+	Priority int    `xml:"priority,attr"`
+	Location string `xml:"location,attr"`
+	URL      string `xml:",chardata"`
+}
+
+// metalinkHashTypes maps Metalink hash types to --checksum algorithms, strongest first.
+var metalinkHashTypes = []struct{ metalink, algorithm string }{
+	{"sha-512", "sha512"},
+	{"sha-256", "sha256"},
+	{"sha-1", "sha1"},
+	{"md5", "md5"},
+}
+
+// LoadMetalink reads and parses a .meta4 file.
+func LoadMetalink(path string) (*Metalink, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metalink file:\n%v", err)
+	}
+	return ParseMetalink(data)
+}
+
+// ParseMetalink parses a Metalink v4 document. File names are reduced to plain names so
+// a document cannot write outside the download directory.
+func ParseMetalink(data []byte) (*Metalink, error) {
+	var metalink Metalink
+	if err := xml.Unmarshal(data, &metalink); err != nil {
+		return nil, fmt.Errorf("error parsing metalink:\n%v", err)
+	}
+	if len(metalink.Files) == 0 {
+		return nil, fmt.Errorf("error parsing metalink: no files listed")
+	}
+
+	for i := range metalink.Files {
+		file := &metalink.Files[i]
+		name := SanitizeFileName(file.Name)
+		if name == "" {
+			return nil, fmt.Errorf("error parsing metalink: invalid file name %q", file.Name)
+		}
+		file.Name = name
+		for j := range file.URLs {
+			file.URLs[j].URL = strings.TrimSpace(file.URLs[j].URL)
+		}
+	}
+	return &metalink, nil
+}
+
+// IsMetalink reports whether resp carries a Metalink v4 document.
+func IsMetalink(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == MetalinkMediaType
+}
+
+// MirrorURLs returns the file's mirror URLs in the order to try them. URLs without a
+// priority come last, and mirrors of equal priority keep their document order.
+func (f *MetalinkFile) MirrorURLs() []string {
+	mirrors := make([]MetalinkURL, 0, len(f.URLs))
+	for _, u := range f.URLs {
+		if u.URL != "" {
+			mirrors = append(mirrors, u)
+		}
+	}
+	rank := func(u MetalinkURL) int {
+		if u.Priority <= 0 {
+			return math.MaxInt
+		}
+		return u.Priority
+	}
+	sort.SliceStable(mirrors, func(a, b int) bool {
+		return rank(mirrors[a]) < rank(mirrors[b])
+	})
+
+	urls := make([]string, len(mirrors))
+	for i, u := range mirrors {
+		urls[i] = u.URL
+	}
+	return urls
+}
+
+// Checksum returns the strongest supported digest of the file, or nil if none is listed.
+func (f *MetalinkFile) Checksum() (*Checksum, error) {
+	for _, candidate := range metalinkHashTypes {
+		for _, h := range f.Hashes {
+			if !strings.EqualFold(h.Type, candidate.metalink) {
+				continue
+			}
+			checksum, err := ParseChecksum(candidate.algorithm + ":" + strings.TrimSpace(h.Value))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing metalink hash for %s:\n%v", f.Name, err)
+			}
+			return checksum, nil
+		}
+	}
+	return nil, nil
+}
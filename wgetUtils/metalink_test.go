@@ -0,0 +1,92 @@
+package wgetutils
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const sampleMetalink = `<?xml version="1.0" encoding="UTF-8"?>
+<metalink xmlns="urn:ietf:params:xml:ns:metalink">
+  <file name="../release-1.0.tar.gz">
+    <size>12</size>
+    <hash type="md5">6f5902ac237024bdd0c176cb93063dc4</hash>
+    <hash type="sha-256">a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447</hash>
+    <url>http://fallback.example/release-1.0.tar.gz</url>
+    <url priority="2" location="us">http://us.example/release-1.0.tar.gz</url>
+    <url priority="1" location="de">
+      http://de.example/release-1.0.tar.gz
+    </url>
+  </file>
+  <file name="NOTES.txt">
+    <url>http://de.example/NOTES.txt</url>
+  </file>
+</metalink>`
+
+func TestParseMetalink(t *testing.T) {
+	metalink, err := ParseMetalink([]byte(sampleMetalink))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metalink.Files) != 2 {
+		t.Fatalf("Expected 2 files, but got %d", len(metalink.Files))
+	}
+
+	release := metalink.Files[0]
+	if release.Name != "release-1.0.tar.gz" {
+		t.Errorf("Expected the name to be reduced to release-1.0.tar.gz, but got %q", release.Name)
+	}
+	if release.Size != 12 {
+		t.Errorf("Expected size 12, but got %d", release.Size)
+	}
+
+	expected := []string{
+		"http://de.example/release-1.0.tar.gz",
+		"http://us.example/release-1.0.tar.gz",
+		"http://fallback.example/release-1.0.tar.gz",
+	}
+	if urls := release.MirrorURLs(); !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected mirrors %v, but got %v", expected, urls)
+	}
+
+	checksum, err := release.Checksum()
+	if err != nil || checksum == nil || checksum.Algorithm != "sha256" {
+		t.Errorf("Expected the sha-256 digest to be preferred, but got %+v (%v)", checksum, err)
+	}
+	if checksum, err := metalink.Files[1].Checksum(); err != nil || checksum != nil {
+		t.Errorf("Expected no digest for NOTES.txt, but got %+v (%v)", checksum, err)
+	}
+}
+
+func TestParseMetalinkErrors(t *testing.T) {
+	tests := []string{
+		`not xml`,
+		`<metalink xmlns="urn:ietf:params:xml:ns:metalink"></metalink>`,
+		`<metalink xmlns="urn:ietf:params:xml:ns:metalink"><file name=".."><url>http://a/b</url></file></metalink>`,
+	}
+
+	for _, data := range tests {
+		if _, err := ParseMetalink([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q, but got none", data)
+		}
+	}
+}
+
+func TestIsMetalink(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"application/metalink4+xml", true},
+		{"application/metalink4+xml; charset=utf-8", true},
+		{"application/xml", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{"Content-Type": {test.contentType}}}
+		if result := IsMetalink(resp); result != test.expected {
+			t.Errorf("Expected %v for %q, but got %v", test.expected, test.contentType, result)
+		}
+	}
+}
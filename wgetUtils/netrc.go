@@ -0,0 +1,143 @@
+package wgetutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NetrcEntry holds the credentials of one machine (or the default) in a .netrc file.
+type NetrcEntry struct {
+	Login    string
+	Password string
+}
+
+// Netrc holds the per-host credentials parsed from a .netrc file.
+type Netrc struct {
+	machines map[string]NetrcEntry
+	fallback *NetrcEntry // the "default" entry, used for hosts without their own entry
+}
+
+// DefaultNetrcPath returns the location of the user's ~/.netrc file.
+func DefaultNetrcPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding home directory:\n %v", err)
+	}
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// LoadNetrc reads and parses a .netrc file. Like ftp and curl, it warns when the file
+// can be read by other users, since it holds passwords in plain text.
+func LoadNetrc(path string) (*Netrc, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening netrc file:\n%v", err)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		fmt.Printf("warning: netrc file %s is accessible by other users (mode %04o); consider chmod 600\n", path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading netrc file:\n%v", err)
+	}
+	return ParseNetrc(string(data))
+}
+
+// ParseNetrc parses the contents of a .netrc file.
+func ParseNetrc(data string) (*Netrc, error) {
+	netrc := &Netrc{machines: make(map[string]NetrcEntry)}
+
+	var (
+		current   *NetrcEntry
+		machine   string
+		isDefault bool
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if isDefault {
+			netrc.fallback = current
+		} else if _, exists := netrc.machines[machine]; !exists {
+			// The first entry for a machine wins, as in other netrc readers
+			netrc.machines[machine] = *current
+		}
+		current = nil
+	}
+
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for j := 0; j < len(fields); j++ {
+			next := func() (string, error) {
+				if j+1 >= len(fields) {
+					return "", fmt.Errorf("error parsing netrc: %q expects a value on line %d", fields[j], i+1)
+				}
+				j++
+				return fields[j], nil
+			}
+
+			switch fields[j] {
+			case "machine":
+				flush()
+				name, err := next()
+				if err != nil {
+					return nil, err
+				}
+				machine, isDefault, current = strings.ToLower(name), false, &NetrcEntry{}
+			case "default":
+				flush()
+				machine, isDefault, current = "", true, &NetrcEntry{}
+			case "login", "password", "account":
+				value, err := next()
+				if err != nil {
+					return nil, err
+				}
+				if current == nil {
+					return nil, fmt.Errorf("error parsing netrc: %q outside of a machine entry on line %d", fields[j-1], i+1)
+				}
+				if fields[j-1] == "login" {
+					current.Login = value
+				} else if fields[j-1] == "password" {
+					current.Password = value
+				}
+			case "macdef":
+				// A macro definition runs until the next empty line
+				flush()
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			default:
+				if strings.HasPrefix(fields[j], "#") {
+					j = len(fields)
+				}
+			}
+		}
+	}
+	flush()
+
+	return netrc, nil
+}
+
+// Lookup returns the credentials for host, falling back to the default entry.
+func (n *Netrc) Lookup(host string) (NetrcEntry, bool) {
+	if n == nil {
+		return NetrcEntry{}, false
+	}
+	if entry, ok := n.machines[strings.ToLower(host)]; ok {
+		return entry, true
+	}
+	if n.fallback != nil {
+		return *n.fallback, true
+	}
+	return NetrcEntry{}, false
+}
@@ -0,0 +1,90 @@
+package wgetutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	netrc, err := ParseNetrc(`# personal servers
+machine example.com login alice password secret
+machine Files.Example.com
+	login bob
+	password hunter2 account ignored
+
+macdef init
+cd /pub
+bin
+
+machine example.com login eve password second
+default login anonymous password guest@
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		host     string
+		expected NetrcEntry
+	}{
+		{"example.com", NetrcEntry{Login: "alice", Password: "secret"}},
+		{"files.example.com", NetrcEntry{Login: "bob", Password: "hunter2"}},
+		{"other.org", NetrcEntry{Login: "anonymous", Password: "guest@"}},
+	}
+
+	for _, test := range tests {
+		entry, ok := netrc.Lookup(test.host)
+		if !ok || entry != test.expected {
+			t.Errorf("Expected %+v for %s, but got %+v (found %v)", test.expected, test.host, entry, ok)
+		}
+	}
+}
+
+func TestParseNetrcErrors(t *testing.T) {
+	tests := []string{
+		"machine",
+		"login alice password secret",
+		"machine example.com login",
+	}
+
+	for _, data := range tests {
+		if _, err := ParseNetrc(data); err == nil {
+			t.Errorf("Expected an error for %q, but got none", data)
+		}
+	}
+}
+
+func TestNetrcLookupWithoutDefault(t *testing.T) {
+	netrc, err := ParseNetrc("machine example.com login alice password secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := netrc.Lookup("other.org"); ok {
+		t.Errorf("Expected no entry for other.org")
+	}
+
+	var missing *Netrc
+	if _, ok := missing.Lookup("example.com"); ok {
+		t.Errorf("Expected no entry from a nil netrc")
+	}
+}
+
+func TestLoadNetrc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(path, []byte("machine example.com login alice password secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	netrc, err := LoadNetrc(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entry, ok := netrc.Lookup("example.com"); !ok || entry.Login != "alice" {
+		t.Errorf("Expected alice for example.com, but got %+v", entry)
+	}
+
+	if _, err := LoadNetrc(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("Expected an error for a missing netrc file")
+	}
+}
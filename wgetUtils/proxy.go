@@ -0,0 +1,61 @@
+package wgetutils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ParseProxy validates a --proxy value. A bare "host:port" is taken as an HTTP proxy,
+// as curl and wget do.
+func ParseProxy(raw string) (*url.URL, error) {
+	withScheme := raw
+	if !strings.Contains(raw, "://") {
+		withScheme = "http://" + raw
+	}
+	proxy, err := url.Parse(withScheme)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q.\nUsage: --proxy=http://host:port or --proxy=socks5://host:port", raw)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxy, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q: expected http, https, socks5 or socks5h", proxy.Scheme)
+}
+
+// proxyFunc picks the proxy for each request: none with --no-proxy, the --proxy URL when
+// given, and otherwise the http_proxy, https_proxy and no_proxy environment variables.
+// Hosts listed in no_proxy bypass an explicit --proxy as well.
+func proxyFunc(opts ClientOptions) (func(*http.Request) (*url.URL, error), error) {
+	if opts.NoProxy {
+		return nil, nil
+	}
+
+	config := httpproxy.FromEnvironment()
+	if opts.Proxy != "" {
+		proxy, err := ParseProxy(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		config.HTTPProxy, config.HTTPSProxy = proxy.String(), proxy.String()
+	}
+	find := config.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxy, err := find(req.URL)
+		if err != nil || proxy == nil {
+			return proxy, err
+		}
+		// The transport sends these as Proxy-Authorization, or as SOCKS5 credentials
+		if opts.ProxyUser != "" {
+			withUser := *proxy
+			withUser.User = url.UserPassword(opts.ProxyUser, opts.ProxyPassword)
+			proxy = &withUser
+		}
+		return proxy, nil
+	}, nil
+}
@@ -0,0 +1,52 @@
+package wgetutils
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SrcsetURLs returns the image URLs listed in a srcset attribute, dropping the width and
+// density descriptors: "a.jpg 1x, b.jpg 2x" gives a.jpg and b.jpg.
+func SrcsetURLs(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+// IsStylesheetLink reports whether a <link> element loads a stylesheet, whose own url()
+// and @import references are then page requisites as well.
+func IsStylesheetLink(n *html.Node) bool {
+	for _, rel := range strings.Fields(attribute(n, "rel")) {
+		if strings.EqualFold(rel, "stylesheet") {
+			return true
+		}
+	}
+	return false
+}
+
+// requisiteRels are the <link rel> values that load something the page needs to render.
+// Others, such as canonical, alternate, next or prev, point at other documents.
+var requisiteRels = map[string]bool{
+	"stylesheet":       true,
+	"icon":             true,
+	"apple-touch-icon": true,
+	"preload":          true,
+	"modulepreload":    true,
+	"manifest":         true,
+}
+
+// IsRequisiteLink reports whether a <link> element loads a page requisite rather than
+// pointing at another document.
+func IsRequisiteLink(n *html.Node) bool {
+	for _, rel := range strings.Fields(attribute(n, "rel")) {
+		if requisiteRels[strings.ToLower(rel)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package wgetutils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestSrcsetURLs(t *testing.T) {
+	got := SrcsetURLs("small.jpg 480w, /img/large.jpg 1080w,  retina.jpg 2x , ")
+	expected := []string{"small.jpg", "/img/large.jpg", "retina.jpg"}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, but got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, but got %v", expected, got)
+		}
+	}
+}
+
+func TestIsStylesheetLink(t *testing.T) {
+	tests := []struct {
+		link     string
+		expected bool
+	}{
+		{`<link rel="stylesheet" href="/site.css">`, true},
+		{`<link rel="alternate Stylesheet" href="/dark.css">`, true},
+		{`<link rel="icon" href="/favicon.ico">`, false},
+	}
+	for _, test := range tests {
+		doc, _ := html.Parse(strings.NewReader(test.link))
+		link := doc.FirstChild.FirstChild.FirstChild // html > head > link
+		if got := IsStylesheetLink(link); got != test.expected {
+			t.Errorf("Expected %v for %s, but got %v", test.expected, test.link, got)
+		}
+	}
+}
+
+func TestIsRequisiteLink(t *testing.T) {
+	tests := []struct {
+		link     string
+		expected bool
+	}{
+		{`<link rel="stylesheet" href="/site.css">`, true},
+		{`<link rel="shortcut icon" href="/favicon.ico">`, true},
+		{`<link rel="modulepreload" href="/app.js">`, true},
+		{`<link rel="manifest" href="/app.webmanifest">`, true},
+		{`<link rel="canonical" href="/post.html">`, false},
+		{`<link rel="alternate" type="application/rss+xml" href="/feed.xml">`, false},
+		{`<link rel="next" href="/page/2">`, false},
+		{`<link href="/no-rel">`, false},
+	}
+	for _, test := range tests {
+		doc, _ := html.Parse(strings.NewReader(test.link))
+		link := doc.FirstChild.FirstChild.FirstChild // html > head > link
+		if got := IsRequisiteLink(link); got != test.expected {
+			t.Errorf("Expected %v for %s, but got %v", test.expected, test.link, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package wgetutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy describes how often and how patiently failed requests are retried.
+type RetryPolicy struct {
+	Tries     int           // total number of attempts, 0 means retry forever
+	WaitRetry time.Duration // upper bound for the wait between two attempts, Retry-After included
+	RetryOn   map[int]bool  // HTTP status codes that are worth another attempt
+}
+
+// baseRetryWait is the backoff before the second attempt; it doubles on every retry.
+const baseRetryWait = time.Second
+
+// retryPolicy is shared by every request sent through HttpRequest.
+var retryPolicy = DefaultRetryPolicy()
+
+// DefaultRetryPolicy returns the policy used when no retry options are given:
+// a single attempt, backing off up to 10 seconds when retries are enabled.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Tries:     1,
+		WaitRetry: 10 * time.Second,
+		RetryOn: map[int]bool{
+			http.StatusTooManyRequests:     true,
+			http.StatusInternalServerError: true,
+			http.StatusBadGateway:          true,
+			http.StatusServiceUnavailable:  true,
+			http.StatusGatewayTimeout:      true,
+		},
+	}
+}
+
+// SetRetryPolicy replaces the retry policy used by every subsequent request.
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicy = policy
+}
+
+// CurrentRetryPolicy returns the retry policy in effect.
+func CurrentRetryPolicy() RetryPolicy {
+	return retryPolicy
+}
+
+// ParseTries parses the --tries value, accepting "inf" for unlimited attempts.
+func ParseTries(value string) (int, error) {
+	if value == "inf" {
+		return 0, nil
+	}
+	tries, err := strconv.Atoi(value)
+	if err != nil || tries < 0 {
+		return 0, fmt.Errorf("invalid tries value.\nUsage: --tries=5 || --tries=inf")
+	}
+	return tries, nil
+}
+
+// ParseStatusList parses a comma separated list of HTTP status codes such as "503,429".
+func ParseStatusList(value string) (map[int]bool, error) {
+	codes := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid HTTP status code %q.\nUsage: --retry-on-http-error=503,429", part)
+		}
+		codes[code] = true
+	}
+	return codes, nil
+}
+
+// CanRetry reports whether another attempt is allowed after the given attempt number.
+func (p RetryPolicy) CanRetry(attempt int) bool {
+	return p.Tries == 0 || attempt < p.Tries
+}
+
+// ShouldRetryStatus reports whether a response with the given status is worth retrying.
+func (p RetryPolicy) ShouldRetryStatus(code int) bool {
+	return p.RetryOn[code]
+}
+
+// Backoff returns how long to wait after the given failed attempt. The wait doubles on
+// every attempt up to WaitRetry, and is jittered so parallel clients don't retry in lockstep.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	wait := baseRetryWait
+	for i := 1; i < attempt && wait < p.WaitRetry; i++ {
+		wait *= 2
+	}
+	if wait > p.WaitRetry {
+		wait = p.WaitRetry
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// isTransient reports whether a failed request may succeed when sent again: a network
+// error or timeout, or a connection the server dropped. Errors such as too many
+// redirects, an untrusted certificate or an unsupported scheme fail the same way every time.
+func isTransient(err error) bool {
+	// *url.Error is itself a net.Error, so look at what it wraps
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryAfter returns the delay requested by a response's Retry-After header,
+// given either as a number of seconds or as an HTTP date.
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,199 @@
+package wgetutils
+
+import (
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseTries(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int
+		expectedErr bool
+	}{
+		{"5", 5, false},
+		{"inf", 0, false},
+		{"0", 0, false},
+		{"-1", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, test := range tests {
+		tries, err := ParseTries(test.input)
+		if err != nil && !test.expectedErr {
+			t.Errorf("Expected no error for %s, but got %v", test.input, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %s, but got none", test.input)
+		} else if tries != test.expected {
+			t.Errorf("Expected %d tries for %s, but got %d", test.expected, test.input, tries)
+		}
+	}
+}
+
+func TestParseStatusList(t *testing.T) {
+	codes, err := ParseStatusList("503, 429")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if !codes[503] || !codes[429] || len(codes) != 2 {
+		t.Errorf("Expected 503 and 429, but got %v", codes)
+	}
+
+	if _, err := ParseStatusList("503,abc"); err == nil {
+		t.Errorf("Expected error for invalid status code, but got none")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{Tries: 5, WaitRetry: 4 * time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 4 * time.Second},
+	}
+
+	for _, test := range tests {
+		wait := policy.Backoff(test.attempt)
+		if wait < test.max/2 || wait > test.max {
+			t.Errorf("Expected backoff between %s and %s for attempt %d, but got %s", test.max/2, test.max, test.attempt, wait)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{"3", 3 * time.Second, true},
+		{"", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if test.header != "" {
+			resp.Header.Set("Retry-After", test.header)
+		}
+		wait, ok := RetryAfter(resp)
+		if ok != test.ok || wait != test.expected {
+			t.Errorf("Expected (%s, %v) for %q, but got (%s, %v)", test.expected, test.ok, test.header, wait, ok)
+		}
+	}
+}
+
+func TestHttpRequestRetriesUnavailable(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer ts.Close()
+
+	defer SetRetryPolicy(CurrentRetryPolicy())
+	policy := DefaultRetryPolicy()
+	policy.Tries = 3
+	SetRetryPolicy(policy)
+
+	resp, err := HttpRequest(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code 200 after retries, but got %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", calls)
+	}
+}
+
+func TestHttpRequestRetriesOnlyTransientErrors(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/slow-down":
+			if calls == 1 {
+				w.Header().Set("Retry-After", "86400")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("Hello, World!"))
+		}
+	}))
+	defer ts.Close()
+
+	defer SetRetryPolicy(CurrentRetryPolicy())
+	policy := DefaultRetryPolicy()
+	policy.Tries = 3
+	policy.WaitRetry = 10 * time.Millisecond
+	SetRetryPolicy(policy)
+	defer SetClientOptions(ClientOptions{})
+	if err := SetClientOptions(ClientOptions{MaxRedirect: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Exceeding --max-redirect fails the same way every time
+	if _, err := HttpRequest(ts.URL + "/loop"); err == nil {
+		t.Fatalf("Expected the redirect loop to fail, but got no error")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 requests for one attempt with 2 redirects, but got %d", calls)
+	}
+
+	// A Retry-After longer than --waitretry is cut short
+	calls = 0
+	start := time.Now()
+	resp, err := HttpRequest(ts.URL + "/slow-down")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected Retry-After to be capped by WaitRetry, but waited %s", elapsed)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&url.Error{Op: "Get", URL: "http://example.com", Err: io.ErrUnexpectedEOF}, true},
+		{&url.Error{Op: "Get", URL: "http://example.com", Err: syscall.ECONNRESET}, true},
+		{&url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{&url.Error{Op: "Get", URL: "http://example.com", Err: errors.New("stopped after 2 redirects")}, false},
+		{&url.Error{Op: "Get", URL: "ftp://example.com", Err: errors.New(`unsupported protocol scheme "ftp"`)}, false},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: x509.UnknownAuthorityError{}}, false},
+	}
+
+	for _, test := range tests {
+		if got := isTransient(test.err); got != test.expected {
+			t.Errorf("Expected isTransient(%v) to be %v, but got %v", test.err, test.expected, got)
+		}
+	}
+}
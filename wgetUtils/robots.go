@@ -0,0 +1,215 @@
+package wgetutils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Robots holds the robots.txt rules that apply to our user agent on one host.
+type Robots struct {
+	CrawlDelay time.Duration // the delay to keep between requests, 0 if none was asked for
+
+	rules       []robotsRule
+	disallowAll bool
+}
+
+// robotsRule is one Allow or Disallow line. Paths may use "*" and a trailing "$".
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsGroup is a set of rules together with the user agents it is written for.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// RobotsAgent returns the product token matched against robots.txt User-agent lines:
+// the name part of a custom User-Agent, or "wget".
+func RobotsAgent() string {
+	agent := clientOptions.UserAgent
+	if agent == "" {
+		return "wget"
+	}
+	agent, _, _ = strings.Cut(strings.TrimSpace(agent), "/")
+	agent, _, _ = strings.Cut(agent, " ")
+	return strings.ToLower(agent)
+}
+
+// FetchRobots downloads and parses robots.txt for the host of rawURL. As in RFC 9309, a
+// missing file (4xx) allows everything while a server error disallows everything.
+func FetchRobots(rawURL string) (*Robots, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+
+	resp, err := HttpRequest(robotsURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching robots.txt:\n%v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &Robots{disallowAll: true}, nil
+	case resp.StatusCode != http.StatusOK:
+		return &Robots{}, nil
+	}
+
+	// Crawlers are only required to read the first 500 KiB
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 500<<10))
+	if err != nil {
+		return nil, fmt.Errorf("error reading robots.txt:\n%v", err)
+	}
+	return ParseRobots(string(data), RobotsAgent()), nil
+}
+
+// ParseRobots parses a robots.txt file and keeps the rules of the groups written for
+// agent, or of the "*" groups when none name it.
+func ParseRobots(data, agent string) *Robots {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inAgents := false
+
+	for _, line := range strings.Split(data, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share the rules that follow them
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, newRobotsRule(key == "allow", value))
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	// A group naming the agent wins over "*" even when it has no rules (RFC 9309)
+	robots := &Robots{}
+	for _, wanted := range []string{strings.ToLower(agent), "*"} {
+		matched := false
+		for _, group := range groups {
+			for _, name := range group.agents {
+				if name == wanted {
+					matched = true
+					robots.rules = append(robots.rules, group.rules...)
+					if group.crawlDelay > robots.CrawlDelay {
+						robots.CrawlDelay = group.crawlDelay
+					}
+					break
+				}
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	return robots
+}
+
+// newRobotsRule compiles a robots.txt path pattern into a regular expression.
+func newRobotsRule(allow bool, path string) robotsRule {
+	anchored := strings.HasSuffix(path, "$")
+	expr := regexp.QuoteMeta(strings.TrimSuffix(path, "$"))
+	expr = "^" + strings.ReplaceAll(expr, `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return robotsRule{allow: allow, length: len(path), pattern: regexp.MustCompile(expr)}
+}
+
+// Allowed reports whether the path (with its query) of a URL may be crawled. The most
+// specific matching rule wins, and Allow wins a tie.
+func (r *Robots) Allowed(path string) bool {
+	if r == nil || path == "/robots.txt" {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > longest || (rule.length == longest && rule.allow) {
+			allowed, longest = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
+
+// MetaRobotsNofollow reports whether a page asks crawlers not to follow its links with
+// <meta name="robots" content="nofollow"> (or "none").
+func MetaRobotsNofollow(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.Data == "meta" && strings.EqualFold(attribute(n, "name"), "robots") {
+		for _, directive := range strings.Split(attribute(n, "content"), ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive == "nofollow" || directive == "none" {
+				return true
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if MetaRobotsNofollow(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNofollowLink reports whether an element carries rel="nofollow".
+func IsNofollowLink(n *html.Node) bool {
+	for _, rel := range strings.Fields(attribute(n, "rel")) {
+		if strings.EqualFold(rel, "nofollow") {
+			return true
+		}
+	}
+	return false
+}
+
+// attribute returns the value of an element's attribute, or "" if it is not set.
+func attribute(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package wgetutils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseRobots(t *testing.T) {
+	data := `# example robots.txt
+User-agent: *
+Disallow: /
+
+User-agent: Wget
+User-agent: other
+Disallow: /private/
+Allow: /private/public.html
+Disallow: /*.pdf$
+Crawl-delay: 1.5
+`
+	robots := ParseRobots(data, "wget")
+	if robots.CrawlDelay != 1500*time.Millisecond {
+		t.Errorf("Expected a crawl delay of 1.5s, but got %v", robots.CrawlDelay)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/index.html", true},
+		{"/private/secret.html", false},
+		{"/private/public.html", true},
+		{"/docs/manual.pdf", false},
+		{"/docs/manual.pdf?download=1", true},
+		{"/robots.txt", true},
+	}
+	for _, test := range tests {
+		if got := robots.Allowed(test.path); got != test.expected {
+			t.Errorf("Expected Allowed(%q) to be %v, but got %v", test.path, test.expected, got)
+		}
+	}
+
+	// Agents without a group of their own fall back to "*"
+	if ParseRobots(data, "curl").Allowed("/index.html") {
+		t.Errorf("Expected the \"*\" group to disallow everything for other agents")
+	}
+
+	// A group naming the agent applies even when it allows everything
+	if !ParseRobots("User-agent: wget\nDisallow:\n\nUser-agent: *\nDisallow: /\n", "wget").Allowed("/page") {
+		t.Errorf("Expected the empty wget group to allow /page instead of falling back to \"*\"")
+	}
+}
+
+func TestFetchRobotsStatus(t *testing.T) {
+	status := http.StatusNotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		status   int
+		expected bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusForbidden, true},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, test := range tests {
+		status = test.status
+		robots, err := FetchRobots(ts.URL + "/page.html")
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if got := robots.Allowed("/page.html"); got != test.expected {
+			t.Errorf("Expected Allowed to be %v after status %d, but got %v", test.expected, test.status, got)
+		}
+	}
+}
+
+func TestNofollow(t *testing.T) {
+	tests := []struct {
+		page     string
+		expected bool
+	}{
+		{`<head><meta name="robots" content="noindex, nofollow"></head>`, true},
+		{`<head><meta name="ROBOTS" content="none"></head>`, true},
+		{`<head><meta name="robots" content="noindex"></head>`, false},
+		{`<head><meta name="description" content="nofollow"></head>`, false},
+	}
+	for _, test := range tests {
+		doc, _ := html.Parse(strings.NewReader(test.page))
+		if got := MetaRobotsNofollow(doc); got != test.expected {
+			t.Errorf("Expected %v for %s, but got %v", test.expected, test.page, got)
+		}
+	}
+
+	doc, _ := html.Parse(strings.NewReader(`<a rel="external nofollow" href="/x">x</a>`))
+	anchor := doc.FirstChild.LastChild.FirstChild // html > body > a
+	if !IsNofollowLink(anchor) {
+		t.Errorf("Expected rel=\"external nofollow\" to be a nofollow link")
+	}
+}
@@ -0,0 +1,57 @@
+package wgetutils
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// IfModifiedSince returns the If-Modified-Since value for the local copy at path, based on
+// its modification time, or "" when there is no local copy to compare with.
+func IfModifiedSince(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return info.ModTime().UTC().Format(http.TimeFormat)
+}
+
+// IsUpToDate reports whether resp shows that the local copy at path does not need to be
+// downloaded again: either a 304 Not Modified, or, from a server that ignores conditional
+// requests, a Last-Modified no newer than the local file together with the same size.
+func IsUpToDate(resp *http.Response, path string) bool {
+	if resp.StatusCode == http.StatusNotModified {
+		return true
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	remote, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	if remote.After(info.ModTime().Truncate(time.Second)) {
+		return false
+	}
+	return resp.ContentLength < 0 || resp.ContentLength == info.Size()
+}
+
+// ApplyLastModified sets the modification time of path to the Last-Modified time sent by
+// the server, so that the next timestamping run can compare against it. Responses
+// without a valid Last-Modified header leave the file alone.
+func ApplyLastModified(path string, header http.Header) error {
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return nil
+	}
+	if err := os.Chtimes(path, time.Now(), modified); err != nil {
+		return fmt.Errorf("error setting file time:\n%v", err)
+	}
+	return nil
+}
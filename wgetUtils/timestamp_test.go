@@ -0,0 +1,76 @@
+package wgetutils
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsUpToDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	local := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, local, local); err != nil {
+		t.Fatal(err)
+	}
+
+	if since := IfModifiedSince(path); since != "Fri, 01 Mar 2024 12:00:00 GMT" {
+		t.Errorf("Expected If-Modified-Since from the file time, but got %q", since)
+	}
+
+	tests := []struct {
+		name         string
+		status       int
+		lastModified time.Time
+		length       int64
+		expected     bool
+	}{
+		{"Not modified", http.StatusNotModified, time.Time{}, -1, true},
+		{"Same time and size", http.StatusOK, local, 10, true},
+		{"Older on the server", http.StatusOK, local.Add(-time.Hour), 10, true},
+		{"Newer on the server", http.StatusOK, local.Add(time.Hour), 10, false},
+		{"Size changed", http.StatusOK, local, 11, false},
+		{"No Last-Modified", http.StatusOK, time.Time{}, 10, false},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{StatusCode: test.status, Header: http.Header{}, ContentLength: test.length}
+		if !test.lastModified.IsZero() {
+			resp.Header.Set("Last-Modified", test.lastModified.Format(http.TimeFormat))
+		}
+		if result := IsUpToDate(resp, path); result != test.expected {
+			t.Errorf("%s: expected %v, but got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestApplyLastModified(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := time.Date(2023, 7, 14, 8, 30, 0, 0, time.UTC)
+	header := http.Header{"Last-Modified": {modified.Format(http.TimeFormat)}}
+	if err := ApplyLastModified(path, header); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("Expected mtime %v, but got %v", modified, info.ModTime())
+	}
+}
+
+func TestIfModifiedSinceWithoutFile(t *testing.T) {
+	if since := IfModifiedSince(filepath.Join(t.TempDir(), "missing")); since != "" {
+		t.Errorf("Expected no header for a missing file, but got %q", since)
+	}
+}
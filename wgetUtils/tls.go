@@ -0,0 +1,109 @@
+package wgetutils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseSecureProtocol maps a --secure-protocol value to the minimum TLS version to accept.
+// "auto" leaves the choice to Go's defaults and returns 0.
+func ParseSecureProtocol(protocol string) (uint16, error) {
+	switch strings.ToLower(protocol) {
+	case "auto":
+		return 0, nil
+	case "tlsv1":
+		return tls.VersionTLS10, nil
+	case "tlsv1_1":
+		return tls.VersionTLS11, nil
+	case "tlsv1_2":
+		return tls.VersionTLS12, nil
+	case "tlsv1_3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("invalid --secure-protocol value %q.\nUsage: --secure-protocol=auto|TLSv1|TLSv1_1|TLSv1_2|TLSv1_3", protocol)
+}
+
+// tlsConfig builds the TLS settings for the shared transport. Extra CA certificates are
+// added to the system pool, so public sites keep working next to privately signed ones.
+func tlsConfig(opts ClientOptions) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: opts.NoCheckCertificate}
+
+	if opts.SecureProtocol != "" {
+		version, err := ParseSecureProtocol(opts.SecureProtocol)
+		if err != nil {
+			return nil, err
+		}
+		config.MinVersion = version
+	}
+
+	if opts.CACertificate != "" || opts.CADirectory != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if opts.CACertificate != "" {
+			if err := addCAFile(pool, opts.CACertificate); err != nil {
+				return nil, err
+			}
+		}
+		if opts.CADirectory != "" {
+			if err := addCADirectory(pool, opts.CADirectory); err != nil {
+				return nil, err
+			}
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.Certificate != "" {
+		// Like wget, the private key may live in the certificate file itself
+		keyFile := opts.PrivateKey
+		if keyFile == "" {
+			keyFile = opts.Certificate
+		}
+		cert, err := tls.LoadX509KeyPair(opts.Certificate, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate:\n%v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	} else if opts.PrivateKey != "" {
+		return nil, fmt.Errorf("error: --private-key requires --certificate")
+	}
+
+	return config, nil
+}
+
+// addCAFile adds every PEM certificate in path to pool.
+func addCAFile(pool *x509.CertPool, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading CA certificate:\n%v", err)
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("error reading CA certificate:\nno PEM certificates found in %s", path)
+	}
+	return nil
+}
+
+// addCADirectory adds the PEM certificates of every file in dir to pool. Files that hold
+// no certificates, such as an index or README, are skipped.
+func addCADirectory(pool *x509.CertPool, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading CA directory:\n%v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("error reading CA directory:\n%v", err)
+		}
+		pool.AppendCertsFromPEM(data)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package wgetutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSecureProtocol(t *testing.T) {
+	tests := []struct {
+		protocol    string
+		expected    uint16
+		expectedErr bool
+	}{
+		{"auto", 0, false},
+		{"TLSv1_2", tls.VersionTLS12, false},
+		{"tlsv1_3", tls.VersionTLS13, false},
+		{"SSLv3", 0, true},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		version, err := ParseSecureProtocol(test.protocol)
+		if err != nil && !test.expectedErr {
+			t.Errorf("Unexpected error for %q: %v", test.protocol, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("Expected error for %q, but got none", test.protocol)
+		} else if version != test.expected {
+			t.Errorf("Expected %x for %q, but got %x", test.expected, test.protocol, version)
+		}
+	}
+}
+
+// writeServerCA saves the certificate of a test TLS server as a PEM file.
+func writeServerCA(t *testing.T, ts *httptest.Server, path string) {
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCustomCertificateAuthority(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeServerCA(t, ts, caFile)
+
+	tests := []struct {
+		name        string
+		opts        ClientOptions
+		expectedErr bool
+	}{
+		{"system roots only", ClientOptions{}, true},
+		{"--ca-certificate", ClientOptions{CACertificate: caFile}, false},
+		{"--ca-directory", ClientOptions{CADirectory: dir}, false},
+		{"--no-check-certificate", ClientOptions{NoCheckCertificate: true}, false},
+	}
+
+	defer SetClientOptions(ClientOptions{})
+	SetRetryPolicy(RetryPolicy{Tries: 1})
+	defer SetRetryPolicy(DefaultRetryPolicy())
+
+	for _, test := range tests {
+		if err := SetClientOptions(test.opts); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		resp, err := HttpRequest(ts.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if err != nil && !test.expectedErr {
+			t.Errorf("%s: expected no error, but got %v", test.name, err)
+		} else if err == nil && test.expectedErr {
+			t.Errorf("%s: expected a certificate error, but got none", test.name)
+		}
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	var presented int
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = len(r.TLS.PeerCertificates)
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeClientCertificate(t, certFile, keyFile)
+
+	defer SetClientOptions(ClientOptions{})
+	err := SetClientOptions(ClientOptions{NoCheckCertificate: true, Certificate: certFile, PrivateKey: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := HttpRequest(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	resp.Body.Close()
+
+	if presented != 1 {
+		t.Errorf("Expected the client certificate to be presented, but got %d certificates", presented)
+	}
+}
+
+func TestSecureProtocolMinimum(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+
+	defer SetClientOptions(ClientOptions{})
+	SetRetryPolicy(RetryPolicy{Tries: 1})
+	defer SetRetryPolicy(DefaultRetryPolicy())
+
+	if err := SetClientOptions(ClientOptions{NoCheckCertificate: true, SecureProtocol: "TLSv1_3"}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := HttpRequest(ts.URL); err == nil {
+		resp.Body.Close()
+		t.Errorf("Expected a TLS 1.2 server to be refused with --secure-protocol=TLSv1_3")
+	}
+}
+
+func TestPrivateKeyRequiresCertificate(t *testing.T) {
+	if _, err := tlsConfig(ClientOptions{PrivateKey: "client.key"}); err == nil {
+		t.Errorf("Expected an error for --private-key without --certificate")
+	}
+}
+
+// writeClientCertificate creates a self-signed client certificate and its key.
+func writeClientCertificate(t *testing.T, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "wget test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}